@@ -1,11 +1,5 @@
 package gj
 
-import (
-	"bytes"
-	"fmt"
-	"strings"
-)
-
 type expression interface {
 	TokenLiteral() string
 	String() string
@@ -63,12 +57,7 @@ type prefixExpression struct {
 
 func (pe *prefixExpression) TokenLiteral() string { return pe.Token.Literal }
 func (pe *prefixExpression) String() string {
-	var out bytes.Buffer
-
-	out.WriteString(pe.Operator)
-	out.WriteString(pe.Right.String())
-
-	return out.String()
+	return format(pe)
 }
 
 type stringExpression struct {
@@ -77,27 +66,94 @@ type stringExpression struct {
 }
 
 func (s *stringExpression) TokenLiteral() string { return s.Token.Literal }
-func (s *stringExpression) String() string       { return fmt.Sprintf(`"%s"`, s.Token.Literal) }
+func (s *stringExpression) String() string       { return format(s) }
 
 type objectExpression struct {
 	Token token
-	Pairs map[string]expression
+	Pairs []*objectPair  // members in the order they appear in the input
+	index map[string]int // built when the object is created, so that lookups never write
+}
+
+// objectPair is a member of an objectExpression.
+type objectPair struct {
+	Key   *stringExpression
+	Value expression
 }
 
 func (o *objectExpression) TokenLiteral() string { return o.Token.Literal }
 func (o *objectExpression) String() string {
-	var out bytes.Buffer
+	return format(o)
+}
 
-	var pairs []string
-	for key, value := range o.Pairs {
-		pairs = append(pairs, `"`+key+`": `+value.String())
+// get returns the value of the member named key.
+// If the key appears more than once, the value of the last occurrence is returned.
+func (o *objectExpression) get(key string) (expression, bool) {
+	pair, ok := o.pair(key)
+	if !ok {
+		return nil, false
 	}
+	return pair.Value, true
+}
 
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
+// getAll returns the values of every member named key in order.
+func (o *objectExpression) getAll(key string) []expression {
+	var values []expression
+	for _, pair := range o.Pairs {
+		if pair.Key.Value == key {
+			values = append(values, pair.Value)
+		}
+	}
+	return values
+}
 
-	return out.String()
+// pair returns the member named key.
+// If the key appears more than once, the last occurrence is returned.
+func (o *objectExpression) pair(key string) (*objectPair, bool) {
+	i, ok := o.index[key]
+	if !ok {
+		return nil, false
+	}
+	return o.Pairs[i], true
+}
+
+// add appends a member even if a member with the same key already exists.
+func (o *objectExpression) add(key *stringExpression, value expression) {
+	if o.index == nil {
+		o.reindex()
+	}
+	o.index[key.Value] = len(o.Pairs)
+	o.Pairs = append(o.Pairs, &objectPair{Key: key, Value: value})
+}
+
+// set replaces the value of the member named key.Value, keeping its position.
+// If there is no such member, a new one is appended.
+func (o *objectExpression) set(key *stringExpression, value expression) {
+	if pair, ok := o.pair(key.Value); ok {
+		pair.Value = value
+		return
+	}
+	o.add(key, value)
+}
+
+// delete removes every member named key.
+func (o *objectExpression) delete(key string) {
+	pairs := o.Pairs[:0]
+	for _, pair := range o.Pairs {
+		if pair.Key.Value != key {
+			pairs = append(pairs, pair)
+		}
+	}
+	o.Pairs = pairs
+	o.reindex()
+}
+
+// reindex rebuilds the lookup table from key to position in Pairs.
+// Repeated keys map to their last occurrence.
+func (o *objectExpression) reindex() {
+	o.index = make(map[string]int, len(o.Pairs))
+	for i, pair := range o.Pairs {
+		o.index[pair.Key.Value] = i
+	}
 }
 
 type arrayExpression struct {
@@ -107,16 +163,5 @@ type arrayExpression struct {
 
 func (a *arrayExpression) TokenLiteral() string { return a.Token.Literal }
 func (a *arrayExpression) String() string {
-	var out bytes.Buffer
-
-	var values []string
-	for _, v := range a.Values {
-		values = append(values, v.String())
-	}
-
-	out.WriteString("[")
-	out.WriteString(strings.Join(values, ", "))
-	out.WriteString("]")
-
-	return out.String()
+	return format(a)
 }
@@ -11,16 +11,44 @@ func TestString(t *testing.T) {
 				Type:    tokLBrace,
 				Literal: "{",
 			},
-			Pairs: map[string]expression{
-				"foo": &integerExpression{
-					Token: token{Type: tokInt, Literal: "123"},
-					Value: 123,
+			Pairs: []*objectPair{
+				{
+					Key: &stringExpression{Token: token{Type: tokString, Literal: "foo"}, Value: "foo"},
+					Value: &integerExpression{
+						Token: token{Type: tokInt, Literal: "123"},
+						Value: 123,
+					},
+				},
+				{
+					Key:   &stringExpression{Token: token{Type: tokString, Literal: "bar"}, Value: "bar"},
+					Value: &booleanExpression{Token: token{Type: tokTrue, Literal: "true"}, Value: true},
 				},
 			},
 		},
 	}
 
-	if json.String() != `{"foo": 123}` {
+	if json.String() != `{"foo": 123, "bar": true}` {
 		t.Errorf("json.String() wrong. got=%q.", json.String())
 	}
 }
+
+func TestStringExpressionString(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"foo", `"foo"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\dir`, `"C:\\dir"`},
+		{"a\nb\tc\r\b\f", `"a\nb\tc\r\b\f"`},
+		{"\x00\x1f", `"\u0000\u001f"`},
+		{"日本/語", `"日本/語"`},
+	}
+
+	for _, tt := range tests {
+		s := &stringExpression{Token: token{Type: tokString, Literal: tt.value}, Value: tt.value}
+		if s.String() != tt.expected {
+			t.Errorf("s.String() wrong. got=%q, want=%q.", s.String(), tt.expected)
+		}
+	}
+}
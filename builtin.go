@@ -0,0 +1,988 @@
+package gj
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// jqBuiltin is a builtin function of programs. Its arguments are unevaluated, so that functions
+// such as map and select can run them on values of their choice.
+type jqBuiltin func(input expression, args []jqNode, env *jqEnv, emit jqEmit) error
+
+// jqBuiltins holds the builtin functions by name and number of arguments.
+var jqBuiltins = map[string]jqBuiltin{
+	"empty/0":  func(input expression, args []jqNode, env *jqEnv, emit jqEmit) error { return nil },
+	"error/0":  func(input expression, args []jqNode, env *jqEnv, emit jqEmit) error { return &jqError{value: input} },
+	"error/1":  jqFunc1(func(input, msg expression) (expression, error) { return nil, &jqError{value: msg} }),
+	"not/0":    jqFunc0(func(v expression) (expression, error) { return newBoolean(!jqTruthy(v)), nil }),
+	"type/0":   jqFunc0(func(v expression) (expression, error) { return newString(kindOf(v).String()), nil }),
+	"length/0": jqFunc0(jqLength),
+
+	"keys/0":          jqFunc0(func(v expression) (expression, error) { return jqKeys(v, true) }),
+	"keys_unsorted/0": jqFunc0(func(v expression) (expression, error) { return jqKeys(v, false) }),
+	"has/1":           jqFunc1(jqHas),
+	"in/1":            jqFunc1(func(k, v expression) (expression, error) { return jqHas(v, k) }),
+	"contains/1":      jqFunc1(jqContains),
+	"inside/1":        jqFunc1(func(b, a expression) (expression, error) { return jqContains(a, b) }),
+	"add/0":           jqFunc0(jqAdd),
+	"any/0":           jqFunc0(func(v expression) (expression, error) { return jqAnyAll(v, true) }),
+	"all/0":           jqFunc0(func(v expression) (expression, error) { return jqAnyAll(v, false) }),
+	"range/1":         jqRange,
+	"range/2":         jqRange,
+	"floor/0":         jqMath(math.Floor),
+	"ceil/0":          jqMath(math.Ceil),
+	"round/0":         jqMath(math.Round),
+	"sqrt/0":          jqMath(math.Sqrt),
+	"abs/0":           jqMath(math.Abs),
+	"min/0":           jqFunc0(func(v expression) (expression, error) { return jqExtreme(v, nil, -1) }),
+	"max/0":           jqFunc0(func(v expression) (expression, error) { return jqExtreme(v, nil, 1) }),
+	"sort/0":          jqFunc0(func(v expression) (expression, error) { return jqSort(v, nil) }),
+	"unique/0":        jqFunc0(func(v expression) (expression, error) { return jqUnique(v, nil) }),
+	"reverse/0":       jqFunc0(jqReverse),
+	"flatten/0":       jqFunc0(func(v expression) (expression, error) { return jqFlatten(v, 1e9) }),
+	"flatten/1":       jqFunc1(func(v, depth expression) (expression, error) { return jqFlatten(v, jqFloat(depth)) }),
+	"first/0":         jqFunc0(func(v expression) (expression, error) { return jqIndexValue(v, newInteger(0)) }),
+	"last/0":          jqFunc0(func(v expression) (expression, error) { return jqIndexValue(v, newInteger(-1)) }),
+	"to_entries/0":    jqFunc0(jqToEntries),
+	"from_entries/0":  jqFunc0(jqFromEntries),
+
+	"tostring/0": jqFunc0(func(v expression) (expression, error) { return newString(jqToString(v)), nil }),
+	"tonumber/0": jqFunc0(jqToNumber),
+	"tojson/0":   jqFunc0(func(v expression) (expression, error) { return newString(jqText(v)), nil }),
+	"fromjson/0": jqFunc0(jqFromJSON),
+
+	"ascii_downcase/0": jqStringFunc(func(s string) expression { return newString(jqMapASCII(s, 'A', 'Z', 'a'-'A')) }),
+	"ascii_upcase/0":   jqStringFunc(func(s string) expression { return newString(jqMapASCII(s, 'a', 'z', 'A'-'a')) }),
+	"trim/0":           jqStringFunc(func(s string) expression { return newString(strings.TrimSpace(s)) }),
+	"ltrim/0":          jqStringFunc(func(s string) expression { return newString(strings.TrimLeft(s, " \t\n\r\f\v")) }),
+	"rtrim/0":          jqStringFunc(func(s string) expression { return newString(strings.TrimRight(s, " \t\n\r\f\v")) }),
+	"explode/0":        jqStringFunc(jqExplode),
+	"implode/0":        jqFunc0(jqImplode),
+	"startswith/1":     jqFunc1(func(v, s expression) (expression, error) { return jqAffix(v, s, "startswith", strings.HasPrefix) }),
+	"endswith/1":       jqFunc1(func(v, s expression) (expression, error) { return jqAffix(v, s, "endswith", strings.HasSuffix) }),
+	"ltrimstr/1":       jqFunc1(func(v, s expression) (expression, error) { return jqTrimAffix(v, s, strings.TrimPrefix), nil }),
+	"rtrimstr/1":       jqFunc1(func(v, s expression) (expression, error) { return jqTrimAffix(v, s, strings.TrimSuffix), nil }),
+	"split/1":          jqFunc1(jqSplitBy),
+	"join/1":           jqFunc1(jqJoin),
+	"test/1":           jqFunc1(func(v, re expression) (expression, error) { return jqTest(v, re, newString("")) }),
+	"test/2":           jqFunc2(jqTest),
+	"sub/2":            jqReplace(false),
+	"gsub/2":           jqReplace(true),
+
+	"nulls/0":     jqSelectKinds(KindNull),
+	"booleans/0":  jqSelectKinds(KindBoolean),
+	"numbers/0":   jqSelectKinds(KindNumber),
+	"strings/0":   jqSelectKinds(KindString),
+	"arrays/0":    jqSelectKinds(KindArray),
+	"objects/0":   jqSelectKinds(KindObject),
+	"iterables/0": jqSelectKinds(KindArray, KindObject),
+	"scalars/0":   jqSelectKinds(KindNull, KindBoolean, KindNumber, KindString),
+	"values/0":    jqSelectKinds(KindBoolean, KindNumber, KindString, KindArray, KindObject),
+
+	"select/1":       jqSelect,
+	"map/1":          jqMap,
+	"map_values/1":   jqMapValues,
+	"with_entries/1": jqWithEntries,
+	"recurse/0": func(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+		return jqRecurseValues(input, emit)
+	},
+	"recurse/1":   jqRecurseWith,
+	"any/1":       jqAnyAllWith(true),
+	"all/1":       jqAnyAllWith(false),
+	"sort_by/1":   jqByKeys(func(v expression, keys []expression) (expression, error) { return jqSort(v, keys) }),
+	"group_by/1":  jqByKeys(jqGroup),
+	"unique_by/1": jqByKeys(jqUnique),
+	"min_by/1":    jqByKeys(func(v expression, keys []expression) (expression, error) { return jqExtreme(v, keys, -1) }),
+	"max_by/1":    jqByKeys(func(v expression, keys []expression) (expression, error) { return jqExtreme(v, keys, 1) }),
+	"first/1":     jqFirst,
+	"last/1":      jqLast,
+	"limit/2":     jqLimit,
+}
+
+// jqFunc0 adapts a function of the input.
+func jqFunc0(f func(v expression) (expression, error)) jqBuiltin {
+	return func(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+		v, err := f(input)
+		if err != nil {
+			return err
+		}
+		return emit(v)
+	}
+}
+
+// jqFunc1 adapts a function of the input and the value of its argument, which is called for each output
+// of the argument.
+func jqFunc1(f func(v, a expression) (expression, error)) jqBuiltin {
+	return func(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+		return args[0].eval(input, env, func(a expression) error {
+			v, err := f(input, a)
+			if err != nil {
+				return err
+			}
+			return emit(v)
+		})
+	}
+}
+
+// jqFunc2 adapts a function of the input and the values of its two arguments, which is called for
+// each combination of their outputs.
+func jqFunc2(f func(v, a, b expression) (expression, error)) jqBuiltin {
+	return func(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+		return args[1].eval(input, env, func(b expression) error {
+			return args[0].eval(input, env, func(a expression) error {
+				v, err := f(input, a, b)
+				if err != nil {
+					return err
+				}
+				return emit(v)
+			})
+		})
+	}
+}
+
+// jqMath adapts a function of a number.
+func jqMath(f func(float64) float64) jqBuiltin {
+	return jqFunc0(func(v expression) (expression, error) {
+		if kindOf(v) != KindNumber {
+			return nil, jqErrorf("%s number required", jqDescribe(v))
+		}
+		return jqNumber(f(jqFloat(v))), nil
+	})
+}
+
+// jqStringFunc adapts a function of a string.
+func jqStringFunc(f func(s string) expression) jqBuiltin {
+	return jqFunc0(func(v expression) (expression, error) {
+		s, ok := v.(*stringExpression)
+		if !ok {
+			return nil, jqErrorf("%s cannot be used as a string", jqDescribe(v))
+		}
+		return f(s.Value), nil
+	})
+}
+
+// jqSelectKinds returns a function passing on its input only if it has one of kinds.
+func jqSelectKinds(kinds ...Kind) jqBuiltin {
+	return func(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+		for _, k := range kinds {
+			if kindOf(input) == k {
+				return emit(input)
+			}
+		}
+		return nil
+	}
+}
+
+func jqLength(v expression) (expression, error) {
+	switch v := v.(type) {
+	case *nullExpression:
+		return newInteger(0), nil
+	case *booleanExpression:
+		return nil, jqErrorf("%s has no length", jqDescribe(v))
+	case *stringExpression:
+		return newInteger(int64(utf8.RuneCountInString(v.Value))), nil
+	case *arrayExpression:
+		return newInteger(int64(len(v.Values))), nil
+	case *objectExpression:
+		return newInteger(int64(len(members(v)))), nil
+	}
+	return jqNumber(math.Abs(jqFloat(v))), nil
+}
+
+// jqKeys returns the keys of an object, sorted or in input order, or the indices of an array.
+func jqKeys(v expression, sorted bool) (expression, error) {
+	out := newArray()
+	switch v := v.(type) {
+	case *objectExpression:
+		var keys []string
+		if sorted {
+			keys = jqSortedKeys(members(v))
+		} else {
+			seen := map[string]bool{}
+			for _, pair := range v.Pairs {
+				if !seen[pair.Key.Value] {
+					seen[pair.Key.Value] = true
+					keys = append(keys, pair.Key.Value)
+				}
+			}
+		}
+		for _, k := range keys {
+			out.Values = append(out.Values, newString(k))
+		}
+	case *arrayExpression:
+		for i := range v.Values {
+			out.Values = append(out.Values, newInteger(int64(i)))
+		}
+	default:
+		return nil, jqErrorf("%s has no keys", jqDescribe(v))
+	}
+	return out, nil
+}
+
+func jqHas(v, k expression) (expression, error) {
+	switch v := v.(type) {
+	case *objectExpression:
+		if k, ok := k.(*stringExpression); ok {
+			_, found := v.get(k.Value)
+			return newBoolean(found), nil
+		}
+	case *arrayExpression:
+		if kindOf(k) == KindNumber {
+			f := jqFloat(k)
+			return newBoolean(f >= 0 && f < float64(len(v.Values))), nil
+		}
+	}
+	return nil, jqErrorf("cannot check whether %s has a %s key", kindOf(v), kindOf(k))
+}
+
+// jqContains reports whether a contains b: strings by substring, arrays if every element of b
+// is contained in an element of a, objects if every member of b is contained in the same member of a.
+func jqContains(a, b expression) (expression, error) {
+	if kindOf(a) != kindOf(b) {
+		return nil, jqErrorf("%s and %s cannot have their containment checked", jqDescribe(a), jqDescribe(b))
+	}
+	return newBoolean(jqContainsDeep(a, b)), nil
+}
+
+func jqContainsDeep(a, b expression) bool {
+	if kindOf(a) != kindOf(b) {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *stringExpression:
+		return strings.Contains(a.Value, b.(*stringExpression).Value)
+	case *arrayExpression:
+		for _, y := range b.(*arrayExpression).Values {
+			found := false
+			for _, x := range a.Values {
+				if jqContainsDeep(x, y) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	case *objectExpression:
+		for _, pair := range b.(*objectExpression).Pairs {
+			x, ok := a.get(pair.Key.Value)
+			if !ok || !jqContainsDeep(x, pair.Value) {
+				return false
+			}
+		}
+		return true
+	}
+	return jqCompare(a, b) == 0
+}
+
+// jqAdd adds the elements of an array, or the values of an object, together.
+func jqAdd(v expression) (expression, error) {
+	if k := kindOf(v); k != KindArray && k != KindObject && k != KindNull {
+		return nil, jqErrorf("cannot iterate over %s", jqDescribe(v))
+	}
+
+	var sum expression = newNull()
+	for _, x := range jqChildren(v) {
+		var err error
+		if sum, err = jqOperate("+", sum, x); err != nil {
+			return nil, err
+		}
+	}
+	return sum, nil
+}
+
+func jqAnyAll(v expression, any bool) (expression, error) {
+	if kindOf(v) != KindArray {
+		return nil, jqErrorf("cannot iterate over %s", jqDescribe(v))
+	}
+	for _, x := range jqChildren(v) {
+		if jqTruthy(x) == any {
+			return newBoolean(any), nil
+		}
+	}
+	return newBoolean(!any), nil
+}
+
+// jqAnyAllWith returns any(f) or all(f), which test f on each element of the input.
+func jqAnyAllWith(any bool) jqBuiltin {
+	return func(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+		if kindOf(input) != KindArray {
+			return jqErrorf("cannot iterate over %s", jqDescribe(input))
+		}
+		stop := &jqBreak{}
+		err := jqEachValue(jqChildren(input), args[0], env, func(v expression) error {
+			if jqTruthy(v) == any {
+				return stop
+			}
+			return nil
+		})
+		if err != nil && err != error(stop) {
+			return err
+		}
+		return emit(newBoolean((err != nil) == any))
+	}
+}
+
+// jqEachValue passes each output of f, run on each of values, to emit.
+func jqEachValue(values []expression, f jqNode, env *jqEnv, emit jqEmit) error {
+	for _, v := range values {
+		if err := f.eval(v, env, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jqBreak stops an evaluation early. Each use has its own value, so that nested uses are told apart;
+// the field keeps the struct from being zero-size, as pointers to zero-size values may be equal.
+type jqBreak struct{ _ byte }
+
+func (*jqBreak) Error() string { return "break" }
+
+// jqRange passes the integers from 0, or from its first argument, up to its last argument to emit.
+func jqRange(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+	from := jqNode(&jqLiteral{value: newInteger(0)})
+	to := args[0]
+	if len(args) == 2 {
+		from, to = args[0], args[1]
+	}
+
+	return from.eval(input, env, func(f expression) error {
+		return to.eval(input, env, func(t expression) error {
+			if kindOf(f) != KindNumber || kindOf(t) != KindNumber {
+				return jqErrorf("range bounds must be numeric")
+			}
+			for x, end := jqFloat(f), jqFloat(t); x < end; x++ {
+				if err := emit(jqNumber(x)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// jqArrayValues returns the elements of the array v, or an error naming the function name.
+func jqArrayValues(v expression, what string) ([]expression, error) {
+	arr, ok := v.(*arrayExpression)
+	if !ok {
+		return nil, jqErrorf("%s cannot be %s, as it is not an array", jqDescribe(v), what)
+	}
+	return arr.Values, nil
+}
+
+// jqByKeys adapts a function of an array and the keys of its elements, which are the collected outputs
+// of the argument for each element, as used by sort_by and the like.
+func jqByKeys(f func(v expression, keys []expression) (expression, error)) jqBuiltin {
+	return func(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+		values, err := jqArrayValues(input, "sorted")
+		if err != nil {
+			return err
+		}
+
+		keys := make([]expression, len(values))
+		for i, v := range values {
+			key := newArray()
+			err := args[0].eval(v, env, func(k expression) error {
+				key.Values = append(key.Values, k)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			keys[i] = key
+		}
+
+		out, err := f(input, keys)
+		if err != nil {
+			return err
+		}
+		return emit(out)
+	}
+}
+
+// jqSortedIndices returns the positions of the elements of values in the stable order of keys,
+// or of the elements themselves if keys is nil.
+func jqSortedIndices(values, keys []expression) []int {
+	if keys == nil {
+		keys = values
+	}
+	indices := make([]int, len(values))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return jqCompare(keys[indices[i]], keys[indices[j]]) < 0
+	})
+	return indices
+}
+
+func jqSort(v expression, keys []expression) (expression, error) {
+	values, err := jqArrayValues(v, "sorted")
+	if err != nil {
+		return nil, err
+	}
+
+	out := newArray()
+	for _, i := range jqSortedIndices(values, keys) {
+		out.Values = append(out.Values, values[i])
+	}
+	return out, nil
+}
+
+// jqGroup groups the elements of the array v with equal keys, in the order of the keys.
+func jqGroup(v expression, keys []expression) (expression, error) {
+	values := v.(*arrayExpression).Values
+
+	out := newArray()
+	var group *arrayExpression
+	var last expression
+	for _, i := range jqSortedIndices(values, keys) {
+		if group == nil || jqCompare(keys[i], last) != 0 {
+			group = newArray()
+			out.Values = append(out.Values, group)
+		}
+		group.Values = append(group.Values, values[i])
+		last = keys[i]
+	}
+	return out, nil
+}
+
+// jqUnique returns the elements of the array v with distinct keys, sorted by key.
+func jqUnique(v expression, keys []expression) (expression, error) {
+	values, err := jqArrayValues(v, "sorted")
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = values
+	}
+
+	out := newArray()
+	var last expression
+	for _, i := range jqSortedIndices(values, keys) {
+		if last == nil || jqCompare(keys[i], last) != 0 {
+			out.Values = append(out.Values, values[i])
+		}
+		last = keys[i]
+	}
+	return out, nil
+}
+
+// jqExtreme returns the element of the array v with the smallest key if sign is -1, or the largest if it is 1.
+// Among equal keys, min returns the first element and max the last.
+func jqExtreme(v expression, keys []expression, sign int) (expression, error) {
+	values, err := jqArrayValues(v, "sorted")
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = values
+	}
+	if len(values) == 0 {
+		return newNull(), nil
+	}
+
+	best := 0
+	for i := 1; i < len(values); i++ {
+		c := jqCompare(keys[i], keys[best]) * sign
+		if c > 0 || (c == 0 && sign > 0) {
+			best = i
+		}
+	}
+	return values[best], nil
+}
+
+func jqReverse(v expression) (expression, error) {
+	switch v := v.(type) {
+	case *nullExpression:
+		return newArray(), nil
+	case *stringExpression:
+		runes := []rune(v.Value)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return newString(string(runes)), nil
+	case *arrayExpression:
+		out := newArray()
+		for i := len(v.Values) - 1; i >= 0; i-- {
+			out.Values = append(out.Values, v.Values[i])
+		}
+		return out, nil
+	}
+	return nil, jqErrorf("%s cannot be reversed, as it is not an array", jqDescribe(v))
+}
+
+// jqFlatten replaces the arrays nested in the array v by their elements, up to depth levels deep.
+func jqFlatten(v expression, depth float64) (expression, error) {
+	if depth < 0 {
+		return nil, jqErrorf("flatten depth must not be negative")
+	}
+	values, err := jqArrayValues(v, "flattened")
+	if err != nil {
+		return nil, err
+	}
+
+	out := newArray()
+	for _, x := range values {
+		if arr, ok := x.(*arrayExpression); ok && depth > 0 {
+			flat, _ := jqFlatten(arr, depth-1)
+			out.Values = append(out.Values, flat.(*arrayExpression).Values...)
+			continue
+		}
+		out.Values = append(out.Values, x)
+	}
+	return out, nil
+}
+
+// jqToEntries returns the members of the object v as objects with a key and a value.
+func jqToEntries(v expression) (expression, error) {
+	obj, ok := v.(*objectExpression)
+	if !ok {
+		return nil, jqErrorf("%s has no keys", jqDescribe(v))
+	}
+
+	out := newArray()
+	for _, pair := range obj.Pairs {
+		entry := newObject()
+		entry.add(newString("key"), pair.Key)
+		entry.add(newString("value"), pair.Value)
+		out.Values = append(out.Values, entry)
+	}
+	return out, nil
+}
+
+// jqFromEntries builds an object from an array of entries, whose keys are named key, k, name or Name,
+// and whose values are named value, v or Value.
+func jqFromEntries(v expression) (expression, error) {
+	values, err := jqArrayValues(v, "iterated")
+	if err != nil {
+		return nil, err
+	}
+
+	out := newObject()
+	for _, x := range values {
+		entry, ok := x.(*objectExpression)
+		if !ok {
+			return nil, jqErrorf("cannot index %s with \"key\"", kindOf(x))
+		}
+
+		var key, value expression = nil, newNull()
+		for _, name := range []string{"key", "k", "name", "Name", "K", "Key"} {
+			if k, ok := entry.get(name); ok && jqTruthy(k) {
+				key = k
+				break
+			}
+		}
+		for _, name := range []string{"value", "v", "Value", "V"} {
+			if x, ok := entry.get(name); ok {
+				value = x
+				break
+			}
+		}
+
+		switch k := key.(type) {
+		case *stringExpression:
+			out.set(k, value)
+		case *integerExpression, *floatExpression, *prefixExpression, *booleanExpression:
+			out.set(newString(jqText(k)), value)
+		default:
+			return nil, jqErrorf("cannot use %s as object key", jqDescribe(entry))
+		}
+	}
+	return out, nil
+}
+
+func jqToNumber(v expression) (expression, error) {
+	switch v := v.(type) {
+	case *integerExpression, *floatExpression, *prefixExpression:
+		return v, nil
+	case *stringExpression:
+		if j, err := ParseString(v.Value, WithMode(ModeStrict)); err == nil && kindOf(j.json.Value) == KindNumber {
+			return j.json.Value, nil
+		}
+	}
+	return nil, jqErrorf("%s cannot be parsed as a number", jqDescribe(v))
+}
+
+func jqFromJSON(v expression) (expression, error) {
+	s, ok := v.(*stringExpression)
+	if !ok {
+		return nil, jqErrorf("%s cannot be parsed as JSON", jqDescribe(v))
+	}
+	j, err := ParseString(s.Value, WithMode(ModeStrict))
+	if err != nil {
+		return nil, jqErrorf("%s cannot be parsed as JSON", jqDescribe(v))
+	}
+	return j.json.Value, nil
+}
+
+// jqMapASCII shifts the ASCII letters of s between lo and hi by delta.
+func jqMapASCII(s string, lo, hi byte, delta int) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= lo && c <= hi {
+			b[i] = byte(int(c) + delta)
+		}
+	}
+	return string(b)
+}
+
+// jqExplode returns the code points of s.
+func jqExplode(s string) expression {
+	out := newArray()
+	for _, r := range s {
+		out.Values = append(out.Values, newInteger(int64(r)))
+	}
+	return out
+}
+
+// jqImplode returns the string made of the array of code points v.
+func jqImplode(v expression) (expression, error) {
+	values, err := jqArrayValues(v, "imploded")
+	if err != nil {
+		return nil, err
+	}
+
+	var out strings.Builder
+	for _, x := range values {
+		if kindOf(x) != KindNumber {
+			return nil, jqErrorf("unicode code points must be numeric")
+		}
+		out.WriteRune(rune(jqFloat(x)))
+	}
+	return newString(out.String()), nil
+}
+
+// jqAffix applies the test has, as the function name, to the strings v and s.
+func jqAffix(v, s expression, name string, has func(s, affix string) bool) (expression, error) {
+	a, ok := v.(*stringExpression)
+	b, ok2 := s.(*stringExpression)
+	if !ok || !ok2 {
+		return nil, jqErrorf("%s() requires string inputs", name)
+	}
+	return newBoolean(has(a.Value, b.Value)), nil
+}
+
+// jqTrimAffix removes the affix s from v with trim if both are strings, and returns v unchanged otherwise.
+func jqTrimAffix(v, s expression, trim func(s, affix string) string) expression {
+	a, ok := v.(*stringExpression)
+	b, ok2 := s.(*stringExpression)
+	if !ok || !ok2 {
+		return v
+	}
+	return newString(trim(a.Value, b.Value))
+}
+
+func jqSplitBy(v, sep expression) (expression, error) {
+	s, ok := v.(*stringExpression)
+	p, ok2 := sep.(*stringExpression)
+	if !ok || !ok2 {
+		return nil, jqErrorf("split input and separator must be strings")
+	}
+	return jqSplit(s.Value, p.Value), nil
+}
+
+// jqJoin joins the elements of the array v with sep. Null elements count as empty strings, and numbers
+// and booleans are converted to text.
+func jqJoin(v, sep expression) (expression, error) {
+	values, err := jqArrayValues(v, "joined")
+	if err != nil {
+		return nil, err
+	}
+	s, ok := sep.(*stringExpression)
+	if !ok {
+		return nil, jqErrorf("%s cannot be used as a separator", jqDescribe(sep))
+	}
+
+	var out strings.Builder
+	for i, x := range values {
+		if i > 0 {
+			out.WriteString(s.Value)
+		}
+		switch kindOf(x) {
+		case KindNull:
+		case KindArray, KindObject:
+			return nil, jqErrorf("cannot join with %s", kindOf(x))
+		default:
+			out.WriteString(jqToString(x))
+		}
+	}
+	return newString(out.String()), nil
+}
+
+// jqRegexp compiles the regular expression re with flags: i ignores case, x ignores whitespace and comments,
+// s lets . match newlines, and g, which only matters to gsub, is accepted.
+func jqRegexp(re, flags expression) (*regexp.Regexp, error) {
+	r, ok := re.(*stringExpression)
+	if !ok {
+		return nil, jqErrorf("%s cannot be matched, as it is not a string", jqDescribe(re))
+	}
+	f, ok := flags.(*stringExpression)
+	if !ok {
+		return nil, jqErrorf("%s is not a string", jqDescribe(flags))
+	}
+
+	pattern := r.Value
+	prefix := ""
+	for _, c := range f.Value {
+		switch c {
+		case 'i', 's':
+			prefix += string(c)
+		case 'x':
+			pattern = jqStripExtended(pattern)
+		case 'g', 'n':
+		default:
+			return nil, jqErrorf("%s is not a valid modifier string", f.Value)
+		}
+	}
+	if prefix != "" {
+		pattern = "(?" + prefix + ")" + pattern
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, jqErrorf("%s (at offset 0) is not a valid regex: %s", r.Value, err)
+	}
+	return compiled, nil
+}
+
+// jqStripExtended removes the unescaped whitespace and # comments from an extended regular expression.
+func jqStripExtended(pattern string) string {
+	var out strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '\\':
+			out.WriteByte(c)
+			if i+1 < len(pattern) {
+				i++
+				out.WriteByte(pattern[i])
+			}
+		case ' ', '\t', '\n', '\r':
+		case '#':
+			for i < len(pattern) && pattern[i] != '\n' {
+				i++
+			}
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+func jqTest(v, re, flags expression) (expression, error) {
+	s, ok := v.(*stringExpression)
+	if !ok {
+		return nil, jqErrorf("%s cannot be matched, as it is not a string", jqDescribe(v))
+	}
+	compiled, err := jqRegexp(re, flags)
+	if err != nil {
+		return nil, err
+	}
+	return newBoolean(compiled.MatchString(s.Value)), nil
+}
+
+// jqReplace returns sub or, if global is set, gsub. The replacement is run on an object holding the named
+// captures of each match, and every combination of its outputs gives a result.
+func jqReplace(global bool) jqBuiltin {
+	return func(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+		s, ok := input.(*stringExpression)
+		if !ok {
+			return jqErrorf("%s cannot be matched, as it is not a string", jqDescribe(input))
+		}
+
+		return args[0].eval(input, env, func(re expression) error {
+			compiled, err := jqRegexp(re, newString(""))
+			if err != nil {
+				return err
+			}
+
+			n := 1
+			if global {
+				n = -1
+			}
+			matches := compiled.FindAllStringSubmatchIndex(s.Value, n)
+
+			var build func(i, from int, prefix string) error
+			build = func(i, from int, prefix string) error {
+				if i == len(matches) {
+					return emit(newString(prefix + s.Value[from:]))
+				}
+
+				m := matches[i]
+				captures := newObject()
+				for g, name := range compiled.SubexpNames() {
+					if name == "" {
+						continue
+					}
+					var value expression = newNull()
+					if m[2*g] >= 0 {
+						value = newString(s.Value[m[2*g]:m[2*g+1]])
+					}
+					captures.add(newString(name), value)
+				}
+
+				return args[1].eval(captures, env, func(r expression) error {
+					rs, ok := r.(*stringExpression)
+					if !ok {
+						return jqErrorf("%s cannot be added to a string", jqDescribe(r))
+					}
+					return build(i+1, m[1], prefix+s.Value[from:m[0]]+rs.Value)
+				})
+			}
+			return build(0, 0, "")
+		})
+	}
+}
+
+func jqSelect(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+	return args[0].eval(input, env, func(v expression) error {
+		if jqTruthy(v) {
+			return emit(input)
+		}
+		return nil
+	})
+}
+
+// jqMap returns an array of the outputs of f run on each element of the input.
+func jqMap(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+	if k := kindOf(input); k != KindArray && k != KindObject {
+		return jqErrorf("cannot iterate over %s", jqDescribe(input))
+	}
+
+	out := newArray()
+	err := jqEachValue(jqChildren(input), args[0], env, func(v expression) error {
+		out.Values = append(out.Values, v)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return emit(out)
+}
+
+// jqMapValues replaces each value of the input by the first output of f, dropping it if there is none.
+func jqMapValues(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+	first := func(v expression) (expression, error) {
+		var out expression
+		stop := &jqBreak{}
+		err := args[0].eval(v, env, func(x expression) error {
+			out = x
+			return stop
+		})
+		if err != nil && err != error(stop) {
+			return nil, err
+		}
+		return out, nil
+	}
+
+	switch in := input.(type) {
+	case *arrayExpression:
+		out := newArray()
+		for _, v := range in.Values {
+			x, err := first(v)
+			if err != nil {
+				return err
+			}
+			if x != nil {
+				out.Values = append(out.Values, x)
+			}
+		}
+		return emit(out)
+	case *objectExpression:
+		out := newObject()
+		for _, pair := range in.Pairs {
+			x, err := first(pair.Value)
+			if err != nil {
+				return err
+			}
+			if x != nil {
+				out.set(pair.Key, x)
+			}
+		}
+		return emit(out)
+	}
+	return jqErrorf("cannot iterate over %s", jqDescribe(input))
+}
+
+// jqWithEntries runs to_entries | map(f) | from_entries.
+func jqWithEntries(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+	entries, err := jqToEntries(input)
+	if err != nil {
+		return err
+	}
+	return jqMap(entries, args, env, func(mapped expression) error {
+		out, err := jqFromEntries(mapped)
+		if err != nil {
+			return err
+		}
+		return emit(out)
+	})
+}
+
+// jqRecurseWith passes the input to emit, then recursively the outputs of f run on it.
+func jqRecurseWith(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+	if err := emit(input); err != nil {
+		return err
+	}
+	return args[0].eval(input, env, func(v expression) error {
+		return jqRecurseWith(v, args, env, emit)
+	})
+}
+
+// jqFirst passes the first output of f to emit.
+func jqFirst(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+	return jqTake(input, args[0], env, 1, emit)
+}
+
+// jqLast passes the last output of f to emit.
+func jqLast(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+	var last expression
+	err := args[0].eval(input, env, func(v expression) error {
+		last = v
+		return nil
+	})
+	if err != nil || last == nil {
+		return err
+	}
+	return emit(last)
+}
+
+// jqLimit passes the first n outputs of f to emit.
+func jqLimit(input expression, args []jqNode, env *jqEnv, emit jqEmit) error {
+	return args[0].eval(input, env, func(n expression) error {
+		if kindOf(n) != KindNumber {
+			return jqErrorf("invalid limit %s", jqDescribe(n))
+		}
+		return jqTake(input, args[1], env, int(jqFloat(n)), emit)
+	})
+}
+
+// jqTake passes the first n outputs of f to emit, and stops f after them.
+func jqTake(input expression, f jqNode, env *jqEnv, n int, emit jqEmit) error {
+	if n <= 0 {
+		return nil
+	}
+
+	stop := &jqBreak{}
+	count := 0
+	err := f.eval(input, env, func(v expression) error {
+		if err := emit(v); err != nil {
+			return err
+		}
+		if count++; count == n {
+			return stop
+		}
+		return nil
+	})
+	if err == error(stop) {
+		return nil
+	}
+	return err
+}
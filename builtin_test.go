@@ -0,0 +1,118 @@
+package gj
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuiltins(t *testing.T) {
+	tests := []struct {
+		src      string
+		input    string
+		expected []string
+	}{
+		{`[empty, 1]`, `null`, []string{`[1]`}},
+		{`not, (null | not)`, `true`, []string{`false`, `true`}},
+		{`[.[] | type]`, `[null, true, 1, "a", [], {}]`, []string{`["null","boolean","number","string","array","object"]`}},
+		{`[.[] | length]`, `[null, -5, "héllo", [1, 2], {"a": 1}]`, []string{`[0,5,5,2,1]`}},
+		{`keys, keys_unsorted`, `{"b": 1, "a": 2}`, []string{`["a","b"]`, `["b","a"]`}},
+		{`keys`, `[4, 5]`, []string{`[0,1]`}},
+		{`has("a"), has("z"), ("a" | in({"a": 1}))`, `{"a": null}`, []string{`true`, `false`, `true`}},
+		{`has(1), has(2)`, `[1, 2]`, []string{`true`, `false`}},
+		{`contains("bar"), contains("baz")`, `"foobar"`, []string{`true`, `false`}},
+		{`contains({a: [1]}), ({a: [1, 2]} | inside({a: [1, 2, 3], b: 1}))`, `{"a": [1, 2], "b": "x"}`, []string{`true`, `true`}},
+		{`add, ([] | add), (["a", "b"] | add), ([[1], [2]] | add)`, `[1, 2, 3]`, []string{`6`, `null`, `"ab"`, `[1,2]`}},
+		{`any, all, ([] | any, all)`, `[true, false]`, []string{`true`, `false`, `false`, `true`}},
+		{`any(. > 2), all(. > 0)`, `[1, 2, 3]`, []string{`true`, `true`}},
+		{`[range(3)], [range(2; 5)], [range(3; 0)]`, `null`, []string{`[0,1,2]`, `[2,3,4]`, `[]`}},
+		{`[.[] | floor], [.[] | ceil], [.[] | round], [.[] | abs]`, `[1.5, -1.5]`, []string{`[1,-2]`, `[2,-1]`, `[2,-2]`, `[1.5,1.5]`}},
+		{`sqrt`, `16`, []string{`4`}},
+		{`min, max, ([] | min)`, `[3, 1, 2]`, []string{`1`, `3`, `null`}},
+		{`sort`, `[3, "a", null, true, false, [1], {"a": 1}, 1]`, []string{`[null,false,true,1,3,"a",[1],{"a":1}]`}},
+		{`unique, reverse`, `[2, 1, 2, 3]`, []string{`[1,2,3]`, `[3,2,1,2]`}},
+		{`flatten, flatten(1)`, `[1, [2, [3, [4]]]]`, []string{`[1,2,3,4]`, `[1,2,[3,[4]]]`}},
+		{`first, last, ([] | first)`, `[1, 2, 3]`, []string{`1`, `3`, `null`}},
+		{`to_entries`, `{"a": 1, "b": 2}`, []string{`[{"key":"a","value":1},{"key":"b","value":2}]`}},
+		{`from_entries`, `[{"key": "a", "value": 1}, {"k": "b", "v": 2}, {"name": "c"}]`, []string{`{"a":1,"b":2,"c":null}`}},
+		{`with_entries({key: ("x" + .key), value})`, `{"a": 1, "b": 2}`, []string{`{"xa":1,"xb":2}`}},
+		{`tostring, (.[] | tostring)`, `[1, "a"]`, []string{`"[1,\"a\"]"`, `"1"`, `"a"`}},
+		{`[.[] | tonumber]`, `["1.5", 2, "-3"]`, []string{`[1.5,2,-3]`}},
+		{`tojson, (tojson | fromjson)`, `{"a": [1, "x"]}`, []string{`"{\"a\":[1,\"x\"]}"`, `{"a":[1,"x"]}`}},
+		{`ascii_downcase, ascii_upcase`, `"aBc-É"`, []string{`"abc-É"`, `"ABC-É"`}},
+		{`trim, ltrim, rtrim`, `"  a b  "`, []string{`"a b"`, `"a b  "`, `"  a b"`}},
+		{`explode, (explode | implode)`, `"aé"`, []string{`[97,233]`, `"aé"`}},
+		{`startswith("fo"), endswith("fo"), ltrimstr("fo"), rtrimstr("ar"), ltrimstr(1)`, `"foobar"`, []string{`true`, `false`, `"obar"`, `"foob"`, `"foobar"`}},
+		{`split(", "), (split(", ") | join("-")), ([1, null, "a"] | join(","))`, `"a, b, c"`, []string{`["a","b","c"]`, `"a-b-c"`, `"1,,a"`}},
+		{`test("B"), test("B"; "i"), test("^f.o$")`, `"foo"`, []string{`false`, `false`, `true`}},
+		{`test("O"; "i"), test("f o o"; "x")`, `"foo"`, []string{`true`, `true`}},
+		{`sub("o"; "0"), gsub("o"; "0"), gsub("(?<x>[a-z])"; "<\(.x)>")`, `"foo"`, []string{`"f0o"`, `"f00"`, `"<f><o><o>"`}},
+		{`[.[] | numbers], [.[] | strings], [.[] | iterables], [.[] | scalars], [.[] | values], [.[] | nulls]`, `[1, "a", null, [], {}, true]`,
+			[]string{`[1]`, `["a"]`, `[[],{}]`, `[1,"a",null,true]`, `[1,"a",[],{},true]`, `[null]`}},
+		{`[.[] | booleans], [.[] | arrays], [.[] | objects]`, `[1, true, [], {}]`, []string{`[true]`, `[[]]`, `[{}]`}},
+		{`map(. * 2), map_values(. + 1)`, `[1, 2]`, []string{`[2,4]`, `[2,3]`}},
+		{`map_values(. + 1), map_values(empty)`, `{"a": 1, "b": 2}`, []string{`{"a":2,"b":3}`, `{}`}},
+		{`[recurse], [recurse(.[]?)]`, `[1, [2]]`, []string{`[[1,[2]],1,[2],2]`, `[[1,[2]],1,[2],2]`}},
+		{`[recurse(if . < 3 then . + 1 else empty end)]`, `0`, []string{`[0,1,2,3]`}},
+		{`sort_by(.a), sort_by(.a, .b)`, `[{"a": 2, "b": 1}, {"a": 1, "b": 2}, {"a": 1, "b": 1}]`,
+			[]string{`[{"a":1,"b":2},{"a":1,"b":1},{"a":2,"b":1}]`, `[{"a":1,"b":1},{"a":1,"b":2},{"a":2,"b":1}]`}},
+		{`group_by(.a) | map(length)`, `[{"a": 2}, {"a": 1}, {"a": 2}]`, []string{`[1,2]`}},
+		{`unique_by(length), min_by(length), max_by(length)`, `["ab", "c", "de", "fgh"]`, []string{`["c","ab","fgh"]`, `"c"`, `"fgh"`}},
+		{`first(.[] | select(. > 1)), last(.[]), [limit(0; .[])], [first(empty)]`, `[1, 2, 3]`, []string{`2`, `3`, `[]`, `[]`}},
+		{`[limit(3; recurse(. + 1))]`, `1`, []string{`[1,2,3]`}},
+		{`[first(first(1, 2), 7)]`, `null`, []string{`[1]`}},
+		{`[limit(2; limit(5; 1, 2, 3), 10, 11)]`, `null`, []string{`[1,2]`}},
+		{`any(first(. > 0)), all(first(. > 0))`, `[1, 0]`, []string{`true`, `false`}},
+		{`map_values(first(first(1, 2), 7))`, `{"a": 0}`, []string{`{"a":1}`}},
+	}
+
+	for i, tt := range tests {
+		got, err := runProgram(t, tt.src, tt.input)
+		if err != nil {
+			t.Errorf("[test %d] unexpected error for %q - %q", i, tt.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("[test %d] outputs of %q wrong - got=%q, want=%q.", i, tt.src, got, tt.expected)
+		}
+	}
+}
+
+func TestBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		src      string
+		input    string
+		expected string
+	}{
+		{`length`, `true`, `jq error - boolean (true) has no length`},
+		{`keys`, `1`, `jq error - number (1) has no keys`},
+		{`has(0)`, `{}`, `jq error - cannot check whether object has a number key`},
+		{`add`, `[1, "a"]`, `jq error - number (1) and string ("a") cannot be added`},
+		{`[range("a")]`, `null`, `jq error - range bounds must be numeric`},
+		{`sort`, `{}`, `jq error - object ({}) cannot be sorted, as it is not an array`},
+		{`tonumber`, `"abc"`, `jq error - string ("abc") cannot be parsed as a number`},
+		{`fromjson`, `"{"`, `jq error - string ("{") cannot be parsed as JSON`},
+		{`fromjson`, `"1 2"`, `jq error - string ("1 2") cannot be parsed as JSON`},
+		{`fromjson`, `"[1,]"`, `jq error - string ("[1,]") cannot be parsed as JSON`},
+		{`tonumber`, `"1 2"`, `jq error - string ("1 2") cannot be parsed as a number`},
+		{`ascii_downcase`, `1`, `jq error - number (1) cannot be used as a string`},
+		{`join(",")`, `[[1]]`, `jq error - cannot join with array`},
+		{`test("(")`, `"a"`, "jq error - ( (at offset 0) is not a valid regex: error parsing regexp: missing closing ): `(`"},
+		{`test("a"; "q")`, `"a"`, `jq error - q is not a valid modifier string`},
+		{`implode`, `["a"]`, `jq error - unicode code points must be numeric`},
+		{`from_entries`, `[1]`, `jq error - cannot index number with "key"`},
+		{`with_entries(.value = .value + 1)`, `{"a": 1}`, `jq error - assignment is not supported at position 20 in "with_entries(.value = .value + 1)"`},
+		{`sub("O"; "0"; "gi")`, `"foo"`, `jq error - sub/3 is not defined at position 0 in "sub(\"O\"; \"0\"; \"gi\")"`},
+		{`[limit(3; repeat)]`, `1`, `jq error - repeat/0 is not defined at position 10 in "[limit(3; repeat)]"`},
+	}
+
+	for i, tt := range tests {
+		_, err := runProgram(t, tt.src, tt.input)
+		if err == nil {
+			t.Errorf("[test %d] error expected for %q.", i, tt.src)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("[test %d] unexpected error - got=%q, want=%q.", i, err.Error(), tt.expected)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package gj
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Canonical returns the JSON in the canonical form defined by RFC 8785 (JSON Canonicalization Scheme),
+// which gives the same bytes for equal documents and is suitable for hashing and signing.
+// Members are sorted by the UTF-16 code units of their keys, numbers are written as ECMAScript
+// would write them, and there is no whitespace.
+//
+// An error is returned if the document cannot be canonicalized: if an object has a repeated key,
+// a string is not valid UTF-8, or a number is too large for a float64.
+func (j *JSON) Canonical() ([]byte, error) {
+	var out bytes.Buffer
+	if err := writeCanonical(&out, j.json.Value, ""); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// writeCanonical writes the canonical form of exp, found at path, to out.
+func writeCanonical(out *bytes.Buffer, exp expression, path string) error {
+	switch exp := exp.(type) {
+	case *stringExpression:
+		if !utf8.ValidString(exp.Value) {
+			return fmt.Errorf(`value error - invalid UTF-8 in string at "%s"`, path)
+		}
+		writeString(out, exp.Value, false)
+	case *integerExpression, *floatExpression, *prefixExpression:
+		f, err := strconv.ParseFloat(numberLiteral(exp), 64)
+		if err != nil {
+			return fmt.Errorf(`value error - number %s out of range at "%s"`, numberLiteral(exp), path)
+		}
+		out.WriteString(formatNumber(f))
+	case *objectExpression:
+		pairs := make([]*objectPair, len(exp.Pairs))
+		copy(pairs, exp.Pairs)
+		keys := make(map[*objectPair][]uint16, len(pairs))
+		for _, pair := range pairs {
+			keys[pair] = utf16.Encode([]rune(pair.Key.Value))
+		}
+		sort.Slice(pairs, func(i, j int) bool {
+			return compareUTF16(keys[pairs[i]], keys[pairs[j]]) < 0
+		})
+
+		out.WriteByte('{')
+		for i, pair := range pairs {
+			memberPath := joinPath(path, pair.Key.Value)
+			if i > 0 {
+				if pairs[i-1].Key.Value == pair.Key.Value {
+					return fmt.Errorf(`key error - duplicate key "%s"`, memberPath)
+				}
+				out.WriteByte(',')
+			}
+			if !utf8.ValidString(pair.Key.Value) {
+				return fmt.Errorf(`value error - invalid UTF-8 in key at "%s"`, memberPath)
+			}
+			writeString(out, pair.Key.Value, false)
+			out.WriteByte(':')
+			if err := writeCanonical(out, pair.Value, memberPath); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	case *arrayExpression:
+		out.WriteByte('[')
+		for i, value := range exp.Values {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := writeCanonical(out, value, indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	default:
+		out.WriteString(exp.TokenLiteral())
+	}
+
+	return nil
+}
+
+// compareUTF16 compares a and b code unit by code unit.
+func compareUTF16(a, b []uint16) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// formatNumber formats the finite number f as the ECMAScript Number.prototype.toString method does.
+func formatNumber(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = math.Abs(f)
+	}
+
+	// The shortest digits that round-trip, and the exponent n such that f = 0.digits × 10^n.
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exp := s, 0
+	if i := strings.IndexByte(s, 'e'); i >= 0 {
+		mantissa = s[:i]
+		exp, _ = strconv.Atoi(s[i+1:])
+	}
+	digits := strings.Replace(mantissa, ".", "", 1)
+	k, n := len(digits), exp+1
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	}
+
+	e := "e+"
+	if n-1 < 0 {
+		e = "e-"
+	}
+	abs := n - 1
+	if abs < 0 {
+		abs = -abs
+	}
+	if k == 1 {
+		return sign + digits + e + strconv.Itoa(abs)
+	}
+	return sign + digits[:1] + "." + digits[1:] + e + strconv.Itoa(abs)
+}
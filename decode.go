@@ -0,0 +1,419 @@
+package gj
+
+import (
+	"encoding"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	bigIntType          = reflect.TypeOf((*big.Int)(nil))
+	bigFloatType        = reflect.TypeOf((*big.Float)(nil))
+	bigRatType          = reflect.TypeOf((*big.Rat)(nil))
+)
+
+// Unmarshal parses data and stores the result in the value pointed to by v.
+// It is a shorthand for ParseBytes followed by Decode.
+func Unmarshal(data []byte, v interface{}, opts ...Option) error {
+	j, err := ParseBytes(data, opts...)
+	if err != nil {
+		return err
+	}
+
+	return j.Decode(v)
+}
+
+// Decode stores the JSON in the value pointed to by v.
+//
+// Objects are stored in structs, whose fields are matched by name or by the name given in a
+// `json:"name"` struct tag, preferring an exact match to a case-insensitive one, and in maps with
+// string, integer or encoding.TextUnmarshaler keys. Arrays are stored in slices and arrays, and
+// strings in strings or in values implementing encoding.TextUnmarshaler. Numbers are stored in
+// integer and floating-point types, Number, *big.Int, *big.Float and *big.Rat. Values stored
+// in an empty interface are the same as those returned by Get. Pointers are allocated as needed, and
+// null sets pointers, interfaces, maps and slices to nil and leaves other values unchanged.
+// Object members without a corresponding field are ignored.
+//
+// If a value cannot be stored in the corresponding Go value, Decode stops and returns
+// an *UnmarshalTypeError with the path of the value.
+func (j *JSON) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+
+	d := &decodeState{mode: j.opts.numbers}
+	return d.value(j.json.Value, rv.Elem(), "")
+}
+
+// decodeState holds the settings used while decoding.
+type decodeState struct {
+	mode NumberMode
+}
+
+// value stores exp, found at path, in v.
+func (d *decodeState) value(exp expression, v reflect.Value, path string) error {
+	if _, ok := exp.(*nullExpression); ok {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+
+	v = indirect(v)
+	if isBigNumberType(v.Type()) && kindOf(exp) == KindNumber {
+		return d.bigNumber(exp, v, path)
+	}
+	if v.Kind() == reflect.Ptr {
+		// indirect only stops at a pointer implementing encoding.TextUnmarshaler.
+		s, ok := exp.(*stringExpression)
+		if !ok {
+			return typeError(exp, v.Type().Elem(), path)
+		}
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s.Value)); err != nil {
+			return &UnmarshalTypeError{Value: "string", Type: v.Type().Elem(), Path: path, Err: err}
+		}
+		return nil
+	}
+
+	switch exp := exp.(type) {
+	case *objectExpression:
+		return d.object(exp, v, path)
+	case *arrayExpression:
+		return d.array(exp, v, path)
+	}
+
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		value, err := evalExpression(exp, d.mode)
+		if err != nil {
+			return &UnmarshalTypeError{Value: describe(exp), Type: v.Type(), Path: path, Err: err}
+		}
+		v.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	switch exp := exp.(type) {
+	case *booleanExpression:
+		if v.Kind() != reflect.Bool {
+			return typeError(exp, v.Type(), path)
+		}
+		v.SetBool(exp.Value)
+	case *stringExpression:
+		if v.Kind() != reflect.String || v.Type() == numberType {
+			return typeError(exp, v.Type(), path)
+		}
+		v.SetString(exp.Value)
+	case *integerExpression, *floatExpression, *prefixExpression:
+		return d.number(exp, v, path)
+	default:
+		return typeError(exp, v.Type(), path)
+	}
+
+	return nil
+}
+
+// number stores the number exp, found at path, in v.
+func (d *decodeState) number(exp expression, v reflect.Value, path string) error {
+	literal := numberLiteral(exp)
+
+	if v.Type() == numberType {
+		v.SetString(literal)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(literal, 10, v.Type().Bits())
+		if err != nil {
+			return typeError(exp, v.Type(), path)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(literal, 10, v.Type().Bits())
+		if err != nil {
+			return typeError(exp, v.Type(), path)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(literal, v.Type().Bits())
+		if err != nil {
+			return typeError(exp, v.Type(), path)
+		}
+		v.SetFloat(n)
+	default:
+		return typeError(exp, v.Type(), path)
+	}
+
+	return nil
+}
+
+// bigNumber stores the number exp, found at path, in the *big.Int, *big.Float or *big.Rat v.
+func (d *decodeState) bigNumber(exp expression, v reflect.Value, path string) error {
+	literal := numberLiteral(exp)
+
+	var ok bool
+	switch n := v.Interface().(type) {
+	case *big.Int:
+		_, ok = n.SetString(literal, 10)
+	case *big.Float:
+		if n.Prec() == 0 {
+			n.SetPrec(floatPrecision(literal))
+		}
+		_, ok = n.SetString(literal)
+	case *big.Rat:
+		_, ok = n.SetString(literal)
+	}
+
+	if !ok {
+		return typeError(exp, v.Type().Elem(), path)
+	}
+	return nil
+}
+
+// isBigNumberType reports whether t is *big.Int, *big.Float or *big.Rat.
+func isBigNumberType(t reflect.Type) bool {
+	return t == bigIntType || t == bigFloatType || t == bigRatType
+}
+
+// object stores the object exp, found at path, in v.
+func (d *decodeState) object(exp *objectExpression, v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return typeError(exp, v.Type(), path)
+		}
+		m := make(map[string]interface{})
+		mv := reflect.ValueOf(m)
+		for _, pair := range exp.Pairs {
+			elem := reflect.New(mv.Type().Elem()).Elem()
+			if err := d.value(pair.Value, elem, joinPath(path, pair.Key.Value)); err != nil {
+				return err
+			}
+			m[pair.Key.Value] = elem.Interface()
+		}
+		v.Set(mv)
+	case reflect.Map:
+		t := v.Type()
+		if !validMapKey(t.Key()) {
+			return typeError(exp, t, path)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		for _, pair := range exp.Pairs {
+			elemPath := joinPath(path, pair.Key.Value)
+			key, err := mapKey(pair.Key, t.Key(), elemPath)
+			if err != nil {
+				return err
+			}
+			elem := reflect.New(t.Elem()).Elem()
+			if err := d.value(pair.Value, elem, elemPath); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, elem)
+		}
+	case reflect.Struct:
+		fields := cachedFields(v.Type())
+		for _, pair := range exp.Pairs {
+			f, ok := lookupField(fields, pair.Key.Value)
+			if !ok {
+				continue
+			}
+			fv, ok := fieldByIndex(v, f.index)
+			if !ok {
+				continue
+			}
+			if err := d.value(pair.Value, fv, joinPath(path, pair.Key.Value)); err != nil {
+				return err
+			}
+		}
+	default:
+		return typeError(exp, v.Type(), path)
+	}
+
+	return nil
+}
+
+// array stores the array exp, found at path, in v.
+func (d *decodeState) array(exp *arrayExpression, v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return typeError(exp, v.Type(), path)
+		}
+		a := make([]interface{}, len(exp.Values))
+		av := reflect.ValueOf(a)
+		for i, elem := range exp.Values {
+			if err := d.value(elem, av.Index(i), indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(av)
+	case reflect.Slice:
+		s := reflect.MakeSlice(v.Type(), len(exp.Values), len(exp.Values))
+		for i, elem := range exp.Values {
+			if err := d.value(elem, s.Index(i), indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if i >= len(exp.Values) {
+				v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+				continue
+			}
+			if err := d.value(exp.Values[i], v.Index(i), indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return typeError(exp, v.Type(), path)
+	}
+
+	return nil
+}
+
+// indirect follows pointers from v, allocating them as needed, until it reaches a non-pointer value
+// or a pointer implementing encoding.TextUnmarshaler.
+func indirect(v reflect.Value) reflect.Value {
+	for {
+		if v.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(v.Type()).Implements(textUnmarshalerType) {
+			return v.Addr()
+		}
+		if v.Kind() == reflect.Interface && !v.IsNil() {
+			e := v.Elem()
+			if e.Kind() == reflect.Ptr && !e.IsNil() {
+				v = e
+				continue
+			}
+		}
+		if v.Kind() != reflect.Ptr {
+			return v
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		if v.Type().Implements(textUnmarshalerType) {
+			return v
+		}
+		v = v.Elem()
+	}
+}
+
+// fieldByIndex returns the field of the struct v at index, allocating embedded pointers as needed.
+// It returns false if an embedded pointer to an unexported struct type is nil.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// lookupField returns the field named key, or failing that, the first field whose name matches key case-insensitively.
+func lookupField(fields []field, key string) (field, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// validMapKey reports whether values of type t can be used as map keys when decoding objects.
+func validMapKey(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// mapKey converts the object key, found at path, to a map key of type t.
+func mapKey(key *stringExpression, t reflect.Type, path string) (reflect.Value, error) {
+	kv := reflect.New(t)
+	if u, ok := kv.Interface().(encoding.TextUnmarshaler); ok && t.Kind() != reflect.String {
+		if err := u.UnmarshalText([]byte(key.Value)); err != nil {
+			return reflect.Value{}, &UnmarshalTypeError{Value: "string", Type: t, Path: path, Err: err}
+		}
+		return kv.Elem(), nil
+	}
+
+	kv = kv.Elem()
+	switch t.Kind() {
+	case reflect.String:
+		kv.SetString(key.Value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key.Value, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, &UnmarshalTypeError{Value: "number " + key.Value, Type: t, Path: path}
+		}
+		kv.SetInt(n)
+	default:
+		n, err := strconv.ParseUint(key.Value, 10, t.Bits())
+		if err != nil {
+			return reflect.Value{}, &UnmarshalTypeError{Value: "number " + key.Value, Type: t, Path: path}
+		}
+		kv.SetUint(n)
+	}
+
+	return kv, nil
+}
+
+// typeError returns an error for the value exp, found at path, that cannot be stored in a value of type t.
+func typeError(exp expression, t reflect.Type, path string) error {
+	return &UnmarshalTypeError{Value: describe(exp), Type: t, Path: path}
+}
+
+// describe returns the kind of the value exp, with the literal of numbers.
+func describe(exp expression) string {
+	kind := kindOf(exp)
+	if kind == KindNumber {
+		return "number " + numberLiteral(exp)
+	}
+	return kind.String()
+}
+
+// numberLiteral returns the literal of the number exp, including its sign.
+func numberLiteral(exp expression) string {
+	if pe, ok := exp.(*prefixExpression); ok && pe.Right != nil {
+		return pe.Operator + pe.Right.TokenLiteral()
+	}
+	return exp.TokenLiteral()
+}
+
+// joinPath returns the path of the member key of the object at path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return escapeKey(key)
+	}
+	return path + "." + escapeKey(key)
+}
+
+// indexPath returns the path of the i-th element of the array at path.
+func indexPath(path string, i int) string {
+	if path == "" {
+		return "[" + strconv.Itoa(i) + "]"
+	}
+	return path + ".[" + strconv.Itoa(i) + "]"
+}
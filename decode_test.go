@@ -0,0 +1,258 @@
+package gj
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+)
+
+type decodeAddress struct {
+	StreetAddress string
+	City          string `json:"city"`
+	PostalCode    string `json:"postalCode,omitempty"`
+}
+
+type decodePhoneNumber struct {
+	Type   string `json:"type"`
+	Number string `json:"number"`
+}
+
+type decodePerson struct {
+	FirstName    string `json:"firstName"`
+	LastName     string `json:"lastName"`
+	IsAlive      bool   `json:"isAlive"`
+	Age          int    `json:"age"`
+	Address      *decodeAddress
+	PhoneNumbers []decodePhoneNumber `json:"phoneNumbers"`
+	Children     []string            `json:"children"`
+	Spouse       *decodePerson       `json:"spouse"`
+	Ignored      string              `json:"-"`
+	private      string
+}
+
+func TestUnmarshal(t *testing.T) {
+	input := `{
+  "firstName": "John",
+  "lastName": "Smith",
+  "isAlive": true,
+  "age": 27,
+  "address": {
+    "streetAddress": "21 2nd Street",
+    "city": "New York",
+    "state": "NY",
+    "postalCode": "10021-3100"
+  },
+  "phoneNumbers": [
+    {"type": "home", "number": "212 555-1234"},
+    {"type": "office", "number": "646 555-4567"}
+  ],
+  "children": [],
+  "spouse": null,
+  "Ignored": "x",
+  "private": "x"
+}`
+	expected := decodePerson{
+		FirstName: "John",
+		LastName:  "Smith",
+		IsAlive:   true,
+		Age:       27,
+		Address: &decodeAddress{
+			StreetAddress: "21 2nd Street",
+			City:          "New York",
+			PostalCode:    "10021-3100",
+		},
+		PhoneNumbers: []decodePhoneNumber{
+			{Type: "home", Number: "212 555-1234"},
+			{Type: "office", Number: "646 555-4567"},
+		},
+		Children: []string{},
+	}
+
+	var got decodePerson
+	if err := Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unmarshal wrong - got=%+v, want=%+v.", got, expected)
+	}
+}
+
+type decodeBase struct {
+	ID   int
+	Name string
+}
+
+type decodeEmbedded struct {
+	decodeBase
+	*decodeAddress
+	Name string
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		input    string
+		target   interface{}
+		expected interface{}
+	}{
+		{`1`, new(int), 1},
+		{`-128`, new(int8), int8(-128)},
+		{`255`, new(uint8), uint8(255)},
+		{`-1.5`, new(float64), -1.5},
+		{`1`, new(float32), float32(1)},
+		{`"foo"`, new(string), "foo"},
+		{`true`, new(bool), true},
+		{`1`, new(interface{}), int64(1)},
+		{`{"a": [1, 2.5, "x", null]}`, new(interface{}), map[string]interface{}{"a": []interface{}{int64(1), 2.5, "x", nil}}},
+		{`[1, 2, 3]`, new([]int), []int{1, 2, 3}},
+		{`[1, 2, 3]`, new([2]int), [2]int{1, 2}},
+		{`[1]`, &[2]int{5, 6}, [2]int{1, 0}},
+		{`{"a": 1, "b": 2}`, new(map[string]int), map[string]int{"a": 1, "b": 2}},
+		{`{"1": "a", "-2": "b"}`, new(map[int]string), map[int]string{1: "a", -2: "b"}},
+		{`{"a": {"b": true}}`, new(map[string]map[string]bool), map[string]map[string]bool{"a": {"b": true}}},
+		{`1`, new(*int), func() *int { n := 1; return &n }()},
+		{`null`, &[]int{1}, []int(nil)},
+		{`null`, func() *int { n := 1; return &n }(), 1},
+		{`"127.0.0.1"`, new(net.IP), net.IPv4(127, 0, 0, 1)},
+		{`{"CITY": "Tokyo", "streetaddress": "x"}`, new(decodeAddress), decodeAddress{StreetAddress: "x", City: "Tokyo"}},
+		{`{"ID": 1, "Name": "a", "City": "b"}`, new(decodeEmbedded),
+			decodeEmbedded{decodeBase: decodeBase{ID: 1}, Name: "a"}},
+		{`{"City": "b"}`, &decodeEmbedded{decodeAddress: &decodeAddress{}},
+			decodeEmbedded{decodeAddress: &decodeAddress{City: "b"}}},
+	}
+
+	for i, tt := range tests {
+		json, err := ParseString(tt.input)
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+		if err := json.Decode(tt.target); err != nil {
+			t.Errorf("[test %d] unexpected error - %q", i, err)
+			continue
+		}
+		got := reflect.ValueOf(tt.target).Elem().Interface()
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("[test %d] decode wrong - got=%#v, want=%#v.", i, got, tt.expected)
+		}
+	}
+}
+
+func TestDecodeNumbers(t *testing.T) {
+	var got interface{}
+	if err := Unmarshal([]byte(`[1, 2]`), &got, WithNumbers(NumberFloat64)); err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	expected := []interface{}{1.0, 2.0}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("decode wrong - got=%#v, want=%#v.", got, expected)
+	}
+}
+
+func TestDecodeBigNumbers(t *testing.T) {
+	var got struct {
+		ID     *big.Int
+		Amount big.Float
+		Ratio  *big.Rat
+		Raw    Number
+		Big    interface{}
+	}
+	input := `{"ID": 123456789012345678901234567890, "Amount": 0.1000000000000000000001, "Ratio": 0.75, "Raw": 1.0e2, "Big": 18446744073709551616}`
+	if err := Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	if got.ID.String() != "123456789012345678901234567890" {
+		t.Errorf("ID wrong - got=%s.", got.ID)
+	}
+	if got.Amount.Text('g', -1) != "0.1000000000000000000001" {
+		t.Errorf("Amount wrong - got=%s.", got.Amount.Text('g', -1))
+	}
+	if got.Ratio.String() != "3/4" {
+		t.Errorf("Ratio wrong - got=%s.", got.Ratio)
+	}
+	if got.Raw != "1.0e2" {
+		t.Errorf("Raw wrong - got=%s.", got.Raw)
+	}
+	if got.Big != 18446744073709551616.0 {
+		t.Errorf("Big wrong - got=%#v.", got.Big)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		target   interface{}
+		path     string
+		expected string
+	}{
+		{`"foo"`, new(int), "", `type error - cannot unmarshal string into Go value of type int`},
+		{`300`, new(int8), "", `type error - cannot unmarshal number 300 into Go value of type int8`},
+		{`-1`, new(uint), "", `type error - cannot unmarshal number -1 into Go value of type uint`},
+		{`1.5`, new(int), "", `type error - cannot unmarshal number 1.5 into Go value of type int`},
+		{`1e39`, new(float32), "", `type error - cannot unmarshal number 1e39 into Go value of type float32`},
+		{`[1]`, new(map[string]int), "", `type error - cannot unmarshal array into Go value of type map[string]int`},
+		{`{"a": 1}`, new(map[bool]int), "", `type error - cannot unmarshal object into Go value of type map[bool]int`},
+		{`{"x": 1}`, new(map[int]int), "x", `type error - cannot unmarshal number x into Go value of type int at "x"`},
+		{`{"age": "old"}`, new(decodePerson), "age", `type error - cannot unmarshal string into Go value of type int at "age"`},
+		{`{"phoneNumbers": [{"number": "1"}, {"number": 2}]}`, new(decodePerson), "phoneNumbers.[1].number",
+			`type error - cannot unmarshal number 2 into Go value of type string at "phoneNumbers.[1].number"`},
+		{`{"address": [1]}`, new(decodePerson), "address",
+			`type error - cannot unmarshal array into Go value of type gj.decodeAddress at "address"`},
+		{`"x"`, new(net.IP), "", `type error - cannot unmarshal string into Go value of type net.IP: invalid IP address: x`},
+		{`1`, new(net.IP), "", `type error - cannot unmarshal number 1 into Go value of type net.IP`},
+		{`1`, new(error), "", `type error - cannot unmarshal number 1 into Go value of type error`},
+		{`1.5`, new(*big.Int), "", `type error - cannot unmarshal number 1.5 into Go value of type big.Int`},
+		{`"1"`, new(Number), "", `type error - cannot unmarshal string into Go value of type gj.Number`},
+	}
+
+	for i, tt := range tests {
+		err := Unmarshal([]byte(tt.input), tt.target)
+		if err == nil {
+			t.Errorf("[test %d] expected error - want=%q.", i, tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("[test %d] unexpected error - got=%q, want=%q.", i, err, tt.expected)
+		}
+		var typeErr *UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			t.Errorf("[test %d] error is not an *UnmarshalTypeError - got=%T.", i, err)
+			continue
+		}
+		if typeErr.Path != tt.path {
+			t.Errorf("[test %d] path wrong - got=%q, want=%q.", i, typeErr.Path, tt.path)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	var n int
+	var p *int
+
+	tests := []struct {
+		target   interface{}
+		expected string
+	}{
+		{nil, "type error - cannot unmarshal into nil"},
+		{n, "type error - cannot unmarshal into non-pointer int"},
+		{p, "type error - cannot unmarshal into nil *int"},
+	}
+
+	for i, tt := range tests {
+		err := Unmarshal([]byte(`1`), tt.target)
+		var invalidErr *InvalidUnmarshalError
+		if !errors.As(err, &invalidErr) {
+			t.Errorf("[test %d] error is not an *InvalidUnmarshalError - got=%T.", i, err)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("[test %d] unexpected error - got=%q, want=%q.", i, err, tt.expected)
+		}
+	}
+
+	var syntaxErr *SyntaxError
+	if err := Unmarshal([]byte(`{`), &n); !errors.As(err, &syntaxErr) {
+		t.Errorf("error is not a *SyntaxError - got=%T.", err)
+	}
+}
@@ -0,0 +1,255 @@
+package gj
+
+import (
+	"fmt"
+	"io"
+)
+
+// Token holds a value of one of these types:
+//
+//	Delim, for the four JSON delimiters [ ] { }
+//	bool, for JSON booleans
+//	int64 or float64, for JSON numbers, depending on the NumberMode
+//	string, for JSON strings and object keys
+//	nil, for JSON null
+type Token interface{}
+
+// Delim is a JSON array or object delimiter, one of [ ] { or }.
+type Delim rune
+
+func (d Delim) String() string {
+	return string(d)
+}
+
+// tokenState is the position of a Decoder within the structure of the input.
+type tokenState int
+
+const (
+	tokenTopValue tokenState = iota
+	tokenArrayStart
+	tokenArrayValue
+	tokenArrayComma
+	tokenObjectStart
+	tokenObjectKey
+	tokenObjectColon
+	tokenObjectValue
+	tokenObjectComma
+)
+
+// Decoder reads a stream of JSON tokens and values from an input.
+// Only the token being read and the value being decoded are held in memory,
+// so a large array can be processed element by element.
+type Decoder struct {
+	p     *parser
+	state tokenState
+	stack []tokenState // states to return to when the enclosing arrays and objects are closed
+	err   error
+}
+
+// NewDecoder returns a Decoder reading from r.
+// The input may contain several top-level values one after another.
+func NewDecoder(r io.Reader, opts ...Option) *Decoder {
+	l := newReaderLexer(r, opts...)
+	return &Decoder{p: newParser(l)}
+}
+
+// Token returns the next JSON token in the input.
+// Commas and colons are checked and skipped. At the end of the input, Token returns nil and io.EOF.
+func (d *Decoder) Token() (Token, error) {
+	for {
+		if err := d.checkError(); err != nil {
+			return nil, err
+		}
+
+		tok := d.p.curToken
+		switch tok.Type {
+		case tokLBracket, tokLBrace:
+			if !d.valueAllowed() {
+				return nil, d.unexpected(tok)
+			}
+			if d.p.opts.maxDepth > 0 && len(d.stack) >= d.p.opts.maxDepth {
+				return nil, d.fail(d.p.addError(tok, fmt.Sprintf("maximum nesting depth of %d exceeded.", d.p.opts.maxDepth)))
+			}
+			d.stack = append(d.stack, d.state)
+			if tok.Type == tokLBracket {
+				d.state = tokenArrayStart
+			} else {
+				d.state = tokenObjectStart
+			}
+			d.p.nextToken()
+			return Delim(tok.Literal[0]), nil
+		case tokRBracket:
+			if d.state != tokenArrayStart && d.state != tokenArrayComma {
+				return nil, d.unexpected(tok)
+			}
+			d.end()
+			return Delim(']'), nil
+		case tokRBrace:
+			if d.state != tokenObjectStart && d.state != tokenObjectComma {
+				return nil, d.unexpected(tok)
+			}
+			d.end()
+			return Delim('}'), nil
+		case tokColon:
+			if d.state != tokenObjectColon {
+				return nil, d.unexpected(tok)
+			}
+			d.state = tokenObjectValue
+			d.p.nextToken()
+		case tokComma:
+			switch d.state {
+			case tokenArrayComma:
+				d.state = tokenArrayValue
+			case tokenObjectComma:
+				d.state = tokenObjectKey
+			default:
+				return nil, d.unexpected(tok)
+			}
+			d.p.nextToken()
+		case tokString:
+			if d.state == tokenObjectStart || d.state == tokenObjectKey {
+				d.state = tokenObjectColon
+				d.p.nextToken()
+				return tok.Literal, nil
+			}
+			return d.value()
+		case tokEOF:
+			if d.state != tokenTopValue || len(d.stack) > 0 {
+				return nil, d.unexpected(tok)
+			}
+			return nil, io.EOF
+		default:
+			return d.value()
+		}
+	}
+}
+
+// More reports whether there is another element in the current array or object,
+// or another value at the top level of the input.
+func (d *Decoder) More() bool {
+	if d.err != nil {
+		return false
+	}
+	t := d.p.curToken.Type
+	return t != tokRBracket && t != tokRBrace && t != tokEOF
+}
+
+// Decode reads the next complete value from the input and returns it as a JSON object.
+// It can be mixed with calls to Token, for example to decode the elements of an array one by one.
+// At the end of the input, Decode returns nil and io.EOF.
+func (d *Decoder) Decode() (*JSON, error) {
+	if err := d.prepareForDecode(); err != nil {
+		return nil, err
+	}
+
+	n := len(d.p.errors)
+	d.p.depth = len(d.stack)
+	exp := d.p.parseExpression()
+	if len(d.p.errors) > n {
+		return nil, d.fail(d.p.errors[n])
+	}
+	d.p.nextToken()
+	d.valueEnd()
+
+	return &JSON{json: &jsonExpression{Value: exp}, opts: d.p.opts}, nil
+}
+
+// prepareForDecode skips the comma or colon preceding the next value and checks that a value may follow.
+func (d *Decoder) prepareForDecode() error {
+	if err := d.checkError(); err != nil {
+		return err
+	}
+
+	tok := d.p.curToken
+	switch {
+	case d.state == tokenArrayComma && tok.Type == tokComma:
+		d.state = tokenArrayValue
+		d.p.nextToken()
+	case d.state == tokenObjectColon && tok.Type == tokColon:
+		d.state = tokenObjectValue
+		d.p.nextToken()
+	case d.state == tokenTopValue && tok.Type == tokEOF && len(d.stack) == 0:
+		return io.EOF
+	}
+
+	if err := d.checkError(); err != nil {
+		return err
+	}
+	if !d.valueAllowed() {
+		return d.unexpected(d.p.curToken)
+	}
+
+	return nil
+}
+
+// value reads a scalar value as a token.
+func (d *Decoder) value() (Token, error) {
+	if !d.valueAllowed() {
+		return nil, d.unexpected(d.p.curToken)
+	}
+
+	n := len(d.p.errors)
+	exp := d.p.parseExpression()
+	if len(d.p.errors) > n {
+		return nil, d.fail(d.p.errors[n])
+	}
+	d.p.nextToken()
+	d.valueEnd()
+
+	return evalExpression(exp, d.p.opts.numbers)
+}
+
+// valueAllowed reports whether a value may appear in the current state.
+func (d *Decoder) valueAllowed() bool {
+	switch d.state {
+	case tokenTopValue, tokenArrayStart, tokenArrayValue, tokenObjectValue:
+		return true
+	}
+	return false
+}
+
+// valueEnd updates the state after a complete value has been read.
+func (d *Decoder) valueEnd() {
+	switch d.state {
+	case tokenArrayStart, tokenArrayValue:
+		d.state = tokenArrayComma
+	case tokenObjectValue:
+		d.state = tokenObjectComma
+	}
+}
+
+// end closes the innermost array or object.
+func (d *Decoder) end() {
+	d.state = d.stack[len(d.stack)-1]
+	d.stack = d.stack[:len(d.stack)-1]
+	d.p.nextToken()
+	d.valueEnd()
+}
+
+// checkError returns the first error found so far, if any.
+func (d *Decoder) checkError() error {
+	if d.err != nil {
+		return d.err
+	}
+	if d.p.l.err != nil {
+		return d.fail(d.p.l.err)
+	}
+	if len(d.p.errors) > 0 {
+		return d.fail(d.p.errors[0])
+	}
+	return nil
+}
+
+// unexpected returns an error for a token that is not allowed in the current state.
+func (d *Decoder) unexpected(tok token) error {
+	if d.p.reportTokenError(tok) {
+		return d.fail(tok.Err)
+	}
+	return d.fail(d.p.addError(tok, fmt.Sprintf("unexpected %s.", tok.Type)))
+}
+
+// fail makes err the error returned by every later call.
+func (d *Decoder) fail(err error) error {
+	d.err = err
+	return err
+}
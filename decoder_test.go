@@ -0,0 +1,169 @@
+package gj
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecoderToken(t *testing.T) {
+	input := `{"name": "John", "age": -27, "tags": ["a", 1.5, true, null], "empty": {}} [false]`
+
+	expected := []Token{
+		Delim('{'),
+		"name", "John",
+		"age", int64(-27),
+		"tags", Delim('['), "a", 1.5, true, nil, Delim(']'),
+		"empty", Delim('{'), Delim('}'),
+		Delim('}'),
+		Delim('['), false, Delim(']'),
+	}
+
+	d := NewDecoder(strings.NewReader(input))
+	for i, want := range expected {
+		got, err := d.Token()
+		if err != nil {
+			t.Fatalf("[token %d] unexpected error - %q", i, err.Error())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("[token %d] token wrong. got=%#v, want=%#v.", i, got, want)
+		}
+	}
+
+	if tok, err := d.Token(); tok != nil || err != io.EOF {
+		t.Errorf("expected EOF. got=%#v, %v.", tok, err)
+	}
+}
+
+func TestDecoderDecode(t *testing.T) {
+	input := `[{"id": 1}, {"id": 2, "tags": ["x"]}, {"id": 3}]`
+
+	d := NewDecoder(strings.NewReader(input))
+
+	if tok, err := d.Token(); err != nil || tok != Delim('[') {
+		t.Fatalf("expected [. got=%#v, %v.", tok, err)
+	}
+
+	var ids []interface{}
+	for d.More() {
+		json, err := d.Decode()
+		if err != nil {
+			t.Fatalf("unexpected error - %q", err.Error())
+		}
+		id, err := json.Get("id")
+		if err != nil {
+			t.Fatalf("unexpected error - %q", err.Error())
+		}
+		ids = append(ids, id)
+	}
+
+	if !reflect.DeepEqual(ids, []interface{}{int64(1), int64(2), int64(3)}) {
+		t.Errorf("ids wrong. got=%v.", ids)
+	}
+
+	if tok, err := d.Token(); err != nil || tok != Delim(']') {
+		t.Fatalf("expected ]. got=%#v, %v.", tok, err)
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("expected EOF. got=%v.", err)
+	}
+}
+
+func TestDecoderDecodeObjectMembers(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`{"a": [1, 2], "b": {"c": null}}`))
+
+	d.Token()
+	var got []string
+	for d.More() {
+		key, err := d.Token()
+		if err != nil {
+			t.Fatalf("unexpected error - %q", err.Error())
+		}
+		value, err := d.Decode()
+		if err != nil {
+			t.Fatalf("unexpected error - %q", err.Error())
+		}
+		got = append(got, key.(string)+"="+value.String())
+	}
+
+	expected := []string{"a=[1, 2]", `b={"c": null}`}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("members wrong. got=%q, want=%q.", got, expected)
+	}
+}
+
+func TestDecoderStream(t *testing.T) {
+	d := NewDecoder(strings.NewReader("{\"a\": 1}\n{\"a\": 2}\n3"))
+
+	var got []string
+	for {
+		json, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error - %q", err.Error())
+		}
+		got = append(got, json.String())
+	}
+
+	expected := []string{`{"a": 1}`, `{"a": 2}`, "3"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("values wrong. got=%q, want=%q.", got, expected)
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		opts          []Option
+		expectedError string
+	}{
+		{`[1 2]`, nil, "line 1, column 4: unexpected INT."},
+		{`{"a" 1}`, nil, "line 1, column 6: unexpected INT."},
+		{`{"a": 1,}`, nil, "line 1, column 9: unexpected }."},
+		{`[1, ]`, nil, "line 1, column 5: unexpected ]."},
+		{`{1: 2}`, nil, "line 1, column 2: unexpected INT."},
+		{`[1, "\x"]`, nil, `line 1, column 6: invalid escape sequence "\x" in string.`},
+		{`[1, 2`, nil, "line 1, column 6: unexpected EOF."},
+		{`[[[1]]]`, []Option{WithMaxDepth(2)}, "line 1, column 3: maximum nesting depth of 2 exceeded."},
+	}
+
+	for i, tt := range tests {
+		d := NewDecoder(strings.NewReader(tt.input), tt.opts...)
+
+		var err error
+		for err == nil {
+			_, err = d.Token()
+		}
+
+		if err == io.EOF {
+			t.Fatalf("[test %d] error expected, got EOF.", i)
+		}
+		if err.Error() != tt.expectedError {
+			t.Errorf("[test %d] unexpected error - got=%q, want=%q.", i, err.Error(), tt.expectedError)
+		}
+		if _, again := d.Token(); again != err {
+			t.Errorf("[test %d] error not sticky. got=%v.", i, again)
+		}
+	}
+}
+
+func TestDecoderDecodeErrors(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`[{"id": 1}, {"id" 2}]`))
+	d.Token()
+
+	if _, err := d.Decode(); err != nil {
+		t.Fatalf("unexpected error - %q", err.Error())
+	}
+
+	_, err := d.Decode()
+	expected := "line 1, column 19: expected next token to be COLON, got INT instead."
+	if err == nil || err.Error() != expected {
+		t.Errorf("unexpected error - got=%v, want=%q.", err, expected)
+	}
+	if d.More() {
+		t.Errorf("More() should be false after an error.")
+	}
+}
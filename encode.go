@@ -0,0 +1,425 @@
+package gj
+
+import (
+	"encoding"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	numberType        = reflect.TypeOf(Number(""))
+)
+
+// Marshal returns the JSON encoding of v.
+// It is a shorthand for FromValue followed by String.
+func Marshal(v interface{}) ([]byte, error) {
+	j, err := FromValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(j.String()), nil
+}
+
+// FromValue builds a JSON object from the Go value v.
+//
+// Structs become objects whose members are the exported fields, named and omitted according to
+// their `json:"name,omitempty"` struct tags as described for Decode. Maps with string, integer or
+// encoding.TextMarshaler keys become objects with their keys sorted, slices and arrays become arrays,
+// values implementing encoding.TextMarshaler become strings, and nil pointers, interfaces, maps and
+// slices become null. Floating-point numbers are kept as floats even if they have no fractional part,
+// and *big.Int, *big.Float, *big.Rat and Number values become numbers with all their digits.
+//
+// Channels, functions and complex numbers cannot be represented and cause an *UnsupportedTypeError;
+// NaN, infinities, invalid Number values and cyclic values cause an *UnsupportedValueError.
+func FromValue(v interface{}) (*JSON, error) {
+	e := &encodeState{visited: map[uintptr]bool{}}
+	exp, err := e.value(reflect.ValueOf(v), "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSON{json: &jsonExpression{Value: exp}, opts: newOptions(nil)}, nil
+}
+
+// encodeState holds the values being encoded, to detect cycles.
+type encodeState struct {
+	visited map[uintptr]bool
+}
+
+// value returns the expression for v, found at path.
+func (e *encodeState) value(v reflect.Value, path string) (expression, error) {
+	if !v.IsValid() {
+		return newNull(), nil
+	}
+
+	if exp, ok, err := bigNumberValue(v, path); ok {
+		return exp, err
+	}
+
+	if v.Type().Implements(textMarshalerType) && v.CanInterface() {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return newNull(), nil
+		}
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, &UnsupportedValueError{Value: v.Type().String(), Path: path, Err: err}
+		}
+		return newString(string(text)), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return newBoolean(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return newInteger(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return newNumber(strconv.FormatUint(v.Uint(), 10)), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, &UnsupportedValueError{Value: strconv.FormatFloat(f, 'g', -1, 64), Path: path}
+		}
+		return newFloat(f, v.Type().Bits()), nil
+	case reflect.String:
+		if v.Type() == numberType {
+			if !numberPattern.MatchString(v.String()) {
+				return nil, &UnsupportedValueError{Value: strconv.Quote(v.String()), Path: path}
+			}
+			return newNumber(v.String()), nil
+		}
+		return newString(v.String()), nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return newNull(), nil
+		}
+		return e.value(v.Elem(), path)
+	case reflect.Ptr:
+		if v.IsNil() {
+			return newNull(), nil
+		}
+		if !e.enter(v) {
+			return nil, &UnsupportedValueError{Value: "cycle via " + v.Type().String(), Path: path}
+		}
+		defer e.leave(v)
+		return e.value(v.Elem(), path)
+	case reflect.Struct:
+		return e.structValue(v, path)
+	case reflect.Map:
+		if v.IsNil() {
+			return newNull(), nil
+		}
+		if !e.enter(v) {
+			return nil, &UnsupportedValueError{Value: "cycle via " + v.Type().String(), Path: path}
+		}
+		defer e.leave(v)
+		return e.mapValue(v, path)
+	case reflect.Slice:
+		if v.IsNil() {
+			return newNull(), nil
+		}
+		return e.arrayValue(v, path)
+	case reflect.Array:
+		return e.arrayValue(v, path)
+	}
+
+	return nil, &UnsupportedTypeError{Type: v.Type(), Path: path}
+}
+
+// bigNumberValue returns the number expression for v if it is a *big.Int, *big.Float or *big.Rat,
+// found at path. Rationals without a finite decimal expansion are written as the nearest float64.
+func bigNumberValue(v reflect.Value, path string) (expression, bool, error) {
+	if v.Kind() != reflect.Ptr || !v.CanInterface() {
+		return nil, false, nil
+	}
+
+	var literal string
+	switch n := v.Interface().(type) {
+	case *big.Int:
+		if n == nil {
+			return newNull(), true, nil
+		}
+		literal = n.String()
+	case *big.Float:
+		if n == nil {
+			return newNull(), true, nil
+		}
+		if n.IsInf() {
+			return nil, true, &UnsupportedValueError{Value: n.String(), Path: path}
+		}
+		literal = n.Text('g', -1)
+	case *big.Rat:
+		if n == nil {
+			return newNull(), true, nil
+		}
+		literal = ratLiteral(n)
+	default:
+		return nil, false, nil
+	}
+
+	return newNumber(literal), true, nil
+}
+
+// ratLiteral returns the decimal literal of r, or of the float64 nearest to r if r has no finite
+// decimal expansion.
+func ratLiteral(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	// r has a finite decimal expansion if its denominator has no prime factors but 2 and 5.
+	d := new(big.Int).Set(r.Denom())
+	digits := 0
+	for _, p := range []int64{2, 5} {
+		factor, m := big.NewInt(p), new(big.Int)
+		n := 0
+		for {
+			q, rem := new(big.Int).QuoRem(d, factor, m)
+			if rem.Sign() != 0 {
+				break
+			}
+			d, n = q, n+1
+		}
+		if n > digits {
+			digits = n
+		}
+	}
+	if d.Cmp(big.NewInt(1)) == 0 {
+		return r.FloatString(digits)
+	}
+
+	f, _ := r.Float64()
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// structValue returns the object expression for the struct v, found at path.
+func (e *encodeState) structValue(v reflect.Value, path string) (expression, error) {
+	object := newObject()
+
+	for _, f := range cachedFields(v.Type()) {
+		fv, ok := embeddedField(v, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		value, err := e.value(fv, joinPath(path, f.name))
+		if err != nil {
+			return nil, err
+		}
+		object.add(newString(f.name), value)
+	}
+
+	return object, nil
+}
+
+// mapValue returns the object expression for the map v, found at path.
+func (e *encodeState) mapValue(v reflect.Value, path string) (expression, error) {
+	type member struct {
+		key   string
+		value reflect.Value
+	}
+
+	if !validMarshalKey(v.Type().Key()) {
+		return nil, &UnsupportedTypeError{Type: v.Type(), Path: path}
+	}
+
+	members := make([]member, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKeyString(iter.Key())
+		if err != nil {
+			return nil, &UnsupportedValueError{Value: "key of type " + iter.Key().Type().String(), Path: path, Err: err}
+		}
+		members = append(members, member{key: key, value: iter.Value()})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].key < members[j].key })
+
+	object := newObject()
+	for _, m := range members {
+		value, err := e.value(m.value, joinPath(path, m.key))
+		if err != nil {
+			return nil, err
+		}
+		object.add(newString(m.key), value)
+	}
+
+	return object, nil
+}
+
+// arrayValue returns the array expression for the slice or array v, found at path.
+func (e *encodeState) arrayValue(v reflect.Value, path string) (expression, error) {
+	array := newArray()
+
+	for i := 0; i < v.Len(); i++ {
+		value, err := e.value(v.Index(i), indexPath(path, i))
+		if err != nil {
+			return nil, err
+		}
+		array.Values = append(array.Values, value)
+	}
+
+	return array, nil
+}
+
+// enter marks the pointer or map v as being encoded.
+// It returns false if v is already being encoded, which means that the value is cyclic.
+func (e *encodeState) enter(v reflect.Value) bool {
+	ptr := v.Pointer()
+	if e.visited[ptr] {
+		return false
+	}
+	e.visited[ptr] = true
+	return true
+}
+
+// leave marks the pointer or map v as encoded.
+func (e *encodeState) leave(v reflect.Value) {
+	delete(e.visited, v.Pointer())
+}
+
+// embeddedField returns the field of the struct v at index.
+// It returns false if the field is promoted through a nil embedded pointer.
+func embeddedField(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// validMarshalKey reports whether map keys of type t can be encoded as object keys.
+func validMarshalKey(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return t.Implements(textMarshalerType)
+}
+
+// mapKeyString returns the object key for the map key k.
+func mapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if m, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+
+	if k.Kind() >= reflect.Int && k.Kind() <= reflect.Int64 {
+		return strconv.FormatInt(k.Int(), 10), nil
+	}
+	return strconv.FormatUint(k.Uint(), 10), nil
+}
+
+// isEmptyValue reports whether v is omitted by the omitempty option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// newNull returns a null expression.
+func newNull() expression {
+	return &nullExpression{Token: token{Type: tokNull, Literal: "null"}}
+}
+
+// newBoolean returns a boolean expression for b.
+func newBoolean(b bool) expression {
+	if b {
+		return &booleanExpression{Token: token{Type: tokTrue, Literal: "true"}, Value: true}
+	}
+	return &booleanExpression{Token: token{Type: tokFalse, Literal: "false"}, Value: false}
+}
+
+// newInteger returns a number expression for n.
+// Negative numbers are represented as a prefix expression, as the parser does.
+func newInteger(n int64) expression {
+	if n < 0 {
+		// For math.MinInt64, -n overflows back to n, which is still negated correctly by evalExpression.
+		literal := strconv.FormatUint(uint64(-(n+1))+1, 10)
+		return newNegative(&integerExpression{Token: token{Type: tokInt, Literal: literal}, Value: -n})
+	}
+	return &integerExpression{Token: token{Type: tokInt, Literal: strconv.FormatInt(n, 10)}, Value: n}
+}
+
+// newFloat returns a number expression for f, formatted with the shortest representation
+// for the given bit size.
+func newFloat(f float64, bits int) expression {
+	literal := formatFloat(math.Abs(f), bits)
+	exp := &floatExpression{Token: token{Type: tokFloat, Literal: literal}, Value: literal}
+	if math.Signbit(f) {
+		return newNegative(exp)
+	}
+	return exp
+}
+
+// formatFloat formats the non-negative number f, using an exponent only for very large or small numbers.
+func formatFloat(f float64, bits int) string {
+	format := byte('f')
+	if f != 0 && (f < 1e-6 || f >= 1e21) {
+		format = 'e'
+	}
+	return strconv.FormatFloat(f, format, -1, bits)
+}
+
+// newNumber returns a number expression for the valid number literal, as the parser does.
+func newNumber(literal string) expression {
+	if strings.HasPrefix(literal, "-") {
+		return newNegative(newNumber(literal[1:]))
+	}
+	if n, err := strconv.ParseInt(literal, 10, 64); err == nil {
+		return &integerExpression{Token: token{Type: tokInt, Literal: literal}, Value: n}
+	}
+	typ := tokenType(tokFloat)
+	if isIntegerLiteral(literal) {
+		typ = tokInt
+	}
+	return &floatExpression{Token: token{Type: typ, Literal: literal}, Value: literal}
+}
+
+// newNegative returns a prefix expression negating right.
+func newNegative(right expression) expression {
+	return &prefixExpression{Token: token{Type: tokMinus, Literal: "-"}, Operator: "-", Right: right}
+}
+
+// newString returns a string expression for s.
+func newString(s string) *stringExpression {
+	return &stringExpression{Token: token{Type: tokString, Literal: s}, Value: s}
+}
+
+// newObject returns an empty object expression.
+func newObject() *objectExpression {
+	return &objectExpression{Token: token{Type: tokLBrace, Literal: "{"}, Pairs: []*objectPair{}, index: map[string]int{}}
+}
+
+// newArray returns an empty array expression.
+func newArray() *arrayExpression {
+	return &arrayExpression{Token: token{Type: tokLBracket, Literal: "["}, Values: []expression{}}
+}
@@ -0,0 +1,177 @@
+package gj
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+)
+
+type encodeItem struct {
+	Name  string   `json:"name"`
+	Price float64  `json:"price,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	Note  *string  `json:"note"`
+	Skip  bool     `json:"-"`
+	count int
+}
+
+type encodeEmbedded struct {
+	decodeBase
+	*decodeAddress
+	Name string
+}
+
+// bigInt returns the integer s as a *big.Int.
+func bigInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+func TestMarshal(t *testing.T) {
+	var nilMap map[string]int
+	var nilSlice []int
+	var nilPtr *int
+
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{nil, `null`},
+		{true, `true`},
+		{false, `false`},
+		{42, `42`},
+		{int8(-8), `-8`},
+		{int64(math.MinInt64), `-9223372036854775808`},
+		{uint64(math.MaxInt64), `9223372036854775807`},
+		{uint64(math.MaxUint64), `18446744073709551615`},
+		{1.5, `1.5`},
+		{-0.25, `-0.25`},
+		{1.0, `1`},
+		{float32(0.1), `0.1`},
+		{1e21, `1e+21`},
+		{1e-7, `1e-07`},
+		{math.Copysign(0, -1), `-0`},
+		{"foo", `"foo"`},
+		{nilMap, `null`},
+		{nilSlice, `null`},
+		{nilPtr, `null`},
+		{[]int{}, `[]`},
+		{[2]bool{true, false}, `[true, false]`},
+		{map[string]int{"b": 2, "a": 1}, `{"a": 1, "b": 2}`},
+		{map[int]string{10: "x", 2: "y"}, `{"10": "x", "2": "y"}`},
+		{[]interface{}{1, "a", nil, []int{2}}, `[1, "a", null, [2]]`},
+		{encodeItem{Name: "pen", Skip: true, count: 3}, `{"name": "pen", "note": null}`},
+		{&encodeItem{Name: "pen", Price: 1.2, Tags: []string{"a"}}, `{"name": "pen", "price": 1.2, "tags": ["a"], "note": null}`},
+		{encodeEmbedded{decodeBase: decodeBase{ID: 1, Name: "a"}, Name: "b"}, `{"ID": 1, "Name": "b"}`},
+		{encodeEmbedded{decodeAddress: &decodeAddress{City: "c"}}, `{"ID": 0, "StreetAddress": "", "city": "c", "Name": ""}`},
+		{net.IPv4(127, 0, 0, 1), `"127.0.0.1"`},
+		{Number("-1.50e3"), `-1.50e3`},
+		{bigInt("-123456789012345678901234567890"), `-123456789012345678901234567890`},
+		{big.NewFloat(0.5), `0.5`},
+		{big.NewRat(-3, 8), `-0.375`},
+		{big.NewRat(1, 3), `0.3333333333333333`},
+		{[]*big.Int{big.NewInt(7), nil}, `[7, null]`},
+		{map[string]net.IP{"home": net.IPv4(10, 0, 0, 1)}, `{"home": "10.0.0.1"}`},
+	}
+
+	for i, tt := range tests {
+		got, err := Marshal(tt.input)
+		if err != nil {
+			t.Errorf("[test %d] unexpected error - %q", i, err)
+			continue
+		}
+		if string(got) != tt.expected {
+			t.Errorf("[test %d] marshal wrong - got=%s, want=%s.", i, got, tt.expected)
+		}
+	}
+}
+
+func TestFromValue(t *testing.T) {
+	input := map[string]interface{}{
+		"a": []interface{}{int64(1), -2.5, "x", nil, true},
+		"b": map[string]interface{}{"c": int64(-3)},
+	}
+
+	json, err := FromValue(input)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	got, err := json.Get("")
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if !reflect.DeepEqual(got, input) {
+		t.Errorf("value wrong - got=%#v, want=%#v.", got, input)
+	}
+
+	parsed, err := ParseString(json.String())
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if parsed.String() != json.String() {
+		t.Errorf("reparsed document wrong - got=%s, want=%s.", parsed, json)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	note := "fragile"
+	input := []encodeItem{
+		{Name: "pen", Price: 1.2, Tags: []string{"a", "b"}, Note: &note},
+		{Name: "ink"},
+	}
+
+	data, err := Marshal(input)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	var got []encodeItem
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if !reflect.DeepEqual(got, input) {
+		t.Errorf("round trip wrong - got=%+v, want=%+v.", got, input)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	type cyclic struct {
+		Next *cyclic
+	}
+	c := &cyclic{}
+	c.Next = c
+
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{math.NaN(), `value error - cannot marshal NaN`},
+		{[]float64{1, math.Inf(-1)}, `value error - cannot marshal -Inf at "[1]"`},
+		{map[string]interface{}{"a": make(chan int)}, `type error - cannot marshal Go value of type chan int at "a"`},
+		{map[bool]int{true: 1}, `type error - cannot marshal Go value of type map[bool]int`},
+		{struct{ F func() }{}, `type error - cannot marshal Go value of type func() at "F"`},
+		{c, `value error - cannot marshal cycle via *gj.cyclic at "Next"`},
+		{map[string]Number{"n": "01"}, `value error - cannot marshal "01" at "n"`},
+		{new(big.Float).SetInf(false), `value error - cannot marshal +Inf`},
+	}
+
+	for i, tt := range tests {
+		_, err := Marshal(tt.input)
+		if err == nil {
+			t.Errorf("[test %d] expected error - want=%q.", i, tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("[test %d] unexpected error - got=%q, want=%q.", i, err, tt.expected)
+		}
+		var typeErr *UnsupportedTypeError
+		var valueErr *UnsupportedValueError
+		if !errors.As(err, &typeErr) && !errors.As(err, &valueErr) {
+			t.Errorf("[test %d] error type wrong - got=%T.", i, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package gj
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SyntaxError describes a syntax error found while parsing JSON input.
+// The embedded Position points at the offending token.
+type SyntaxError struct {
+	Position
+	Msg      string // description of the error
+	Expected string // type of the token that was expected, if any
+	Got      string // type of the token that was found, if any
+	Snippet  string // the offending line, followed by a line with a caret under the error; empty if no longer buffered
+	Err      error  // underlying error, if any
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Unwrap returns the underlying error.
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// DuplicateKeyError is reported when an object contains the same key more than once
+// and the parser is configured with DuplicateKeyReject.
+type DuplicateKeyError struct {
+	Key    string
+	First  Position // position of the first occurrence of the key
+	Second Position // position of the repeated occurrence of the key
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("duplicate key %q at line %d, column %d (first defined at line %d, column %d)",
+		e.Key, e.Second.Line, e.Second.Column, e.First.Line, e.First.Column)
+}
+
+// UnmarshalTypeError is returned by Decode and Unmarshal when a JSON value cannot be stored in a Go value.
+type UnmarshalTypeError struct {
+	Value string       // description of the JSON value, such as "string" or "number 300"
+	Type  reflect.Type // type of the Go value it could not be stored in
+	Path  string       // path of the JSON value, in the syntax used by Get; empty for the top-level value
+	Err   error        // underlying error, if any
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	msg := fmt.Sprintf("type error - cannot unmarshal %s into Go value of type %s", e.Value, e.Type)
+	if e.Path != "" {
+		msg += fmt.Sprintf(` at "%s"`, e.Path)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *UnmarshalTypeError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidUnmarshalError is returned by Decode and Unmarshal when the destination is not a non-nil pointer.
+type InvalidUnmarshalError struct {
+	Type reflect.Type
+}
+
+func (e *InvalidUnmarshalError) Error() string {
+	if e.Type == nil {
+		return "type error - cannot unmarshal into nil"
+	}
+	if e.Type.Kind() != reflect.Ptr {
+		return fmt.Sprintf("type error - cannot unmarshal into non-pointer %s", e.Type)
+	}
+	return fmt.Sprintf("type error - cannot unmarshal into nil %s", e.Type)
+}
+
+// UnsupportedTypeError is returned by FromValue and Marshal when a Go value of an unsupported type is encountered.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+	Path string // path of the value, in the syntax used by Get; empty for the top-level value
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	msg := fmt.Sprintf("type error - cannot marshal Go value of type %s", e.Type)
+	if e.Path != "" {
+		msg += fmt.Sprintf(` at "%s"`, e.Path)
+	}
+	return msg
+}
+
+// UnsupportedValueError is returned by FromValue and Marshal when a Go value cannot be represented in JSON.
+type UnsupportedValueError struct {
+	Value string // description of the value, such as "NaN"
+	Path  string // path of the value, in the syntax used by Get; empty for the top-level value
+	Err   error  // underlying error, if any
+}
+
+func (e *UnsupportedValueError) Error() string {
+	msg := fmt.Sprintf("value error - cannot marshal %s", e.Value)
+	if e.Path != "" {
+		msg += fmt.Sprintf(` at "%s"`, e.Path)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *UnsupportedValueError) Unwrap() error {
+	return e.Err
+}
+
+// TypeMismatchError is returned by the typed getters when the value at a path is not of the requested type.
+type TypeMismatchError struct {
+	Path     string
+	Expected string // requested type, such as "string" or "integer"
+	Actual   string // JSON type of the value: "object", "array", "string", "number", "boolean" or "null"
+}
+
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf(`type error - "%s" is %s, expected %s`, e.Path, e.Actual, e.Expected)
+}
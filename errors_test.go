@@ -0,0 +1,58 @@
+package gj
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("a", 50)
+
+	tests := []struct {
+		input    string
+		offset   int
+		expected string
+	}{
+		{"[1, 2 3]", 6, "[1, 2 3]\n      ^"},
+		{"{\n\t\"a\": x\n}", 8, "\t\"a\": x\n\t     ^"},
+		{"[\"é\", x]", 7, "[\"é\", x]\n      ^"},
+		{"[1,", 3, "[1,\n   ^"},
+		{`"` + long + `" x`, 53, `...` + long[12:] + `" x` + "\n" + strings.Repeat(" ", 43) + "^"},
+		{"x" + long, 0, "x" + long[:39] + "\n^"},
+	}
+
+	for i, tt := range tests {
+		l := newLexer(tt.input)
+		got := l.snippet(tt.offset)
+		if got != tt.expected {
+			t.Errorf("[test %d] snippet wrong.\ngot=\n%s\nwant=\n%s", i, got, tt.expected)
+		}
+	}
+}
+
+func TestSnippetTruncatedHistory(t *testing.T) {
+	tests := []struct {
+		data      string
+		offset    int
+		truncated bool
+		expected  string
+	}{
+		{"abc x", 4, false, "abc x\n    ^"},
+		{"abc x", 4, true, "...abc x\n       ^"},
+		{"a\nbc x", 5, true, "bc x\n   ^"},
+	}
+
+	for i, tt := range tests {
+		got := snippet([]byte(tt.data), tt.offset, tt.truncated)
+		if got != tt.expected {
+			t.Errorf("[test %d] snippet wrong. got=%q, want=%q", i, got, tt.expected)
+		}
+	}
+
+	l := newLexer("[" + strings.Repeat(" ", 2*snippetHistory) + "x]")
+	for tok := l.nextToken(); tok.Type != tokEOF; tok = l.nextToken() {
+	}
+	if got := l.snippet(0); got != "" {
+		t.Errorf("snippet for discarded input not empty. got=%q", got)
+	}
+}
@@ -0,0 +1,163 @@
+package gj
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// field is an exported struct field that corresponds to an object member.
+type field struct {
+	name      string
+	index     []int // index sequence for reflect.Value.FieldByIndex
+	typ       reflect.Type
+	omitEmpty bool
+	tagged    bool // whether the name comes from a struct tag
+}
+
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// cachedFields returns the fields of the struct type t, computing them on first use.
+func cachedFields(t reflect.Type) []field {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]field)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return f.([]field)
+}
+
+// typeFields returns the fields of the struct type t in declaration order.
+// The `json:"name,omitempty"` struct tag sets the member name and whether empty values are omitted,
+// and a name of "-" excludes the field. Fields of embedded structs without a tag name are promoted
+// with the same rules as in Go: a shallower field wins, then a tagged one, and ambiguous names are dropped.
+func typeFields(t reflect.Type) []field {
+	var fields []field
+	collectFields(t, nil, map[reflect.Type]bool{}, &fields)
+
+	// Group the candidates by name, keeping the position of the first one for the final order.
+	byName := map[string][]field{}
+	var names []string
+	for _, f := range fields {
+		if _, ok := byName[f.name]; !ok {
+			names = append(names, f.name)
+		}
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	var result []field
+	for _, name := range names {
+		if f, ok := dominantField(byName[name]); ok {
+			result = append(result, f)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return lessIndex(result[i].index, result[j].index)
+	})
+
+	return result
+}
+
+// collectFields appends the candidate fields of the struct type t, found at index, to fields.
+func collectFields(t reflect.Type, index []int, visited map[reflect.Type]bool, fields *[]field) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts := tag, ""
+		if j := strings.Index(tag, ","); j >= 0 {
+			name, opts = tag[:j], tag[j+1:]
+		}
+
+		ft := sf.Type
+		if sf.Anonymous {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if name == "" && ft.Kind() == reflect.Struct {
+				collectFields(ft, appendIndex(index, i), visited, fields)
+				continue
+			}
+			if sf.PkgPath != "" && ft.Kind() != reflect.Struct {
+				continue
+			}
+		} else if sf.PkgPath != "" {
+			continue
+		}
+
+		f := field{
+			name:   name,
+			index:  appendIndex(index, i),
+			typ:    sf.Type,
+			tagged: name != "",
+		}
+		if f.name == "" {
+			f.name = sf.Name
+		}
+		for _, opt := range strings.Split(opts, ",") {
+			if opt == "omitempty" {
+				f.omitEmpty = true
+			}
+		}
+		*fields = append(*fields, f)
+	}
+}
+
+// dominantField returns the field that wins among fields with the same name.
+func dominantField(fields []field) (field, bool) {
+	depth := len(fields[0].index)
+	for _, f := range fields[1:] {
+		if len(f.index) < depth {
+			depth = len(f.index)
+		}
+	}
+
+	var candidates []field
+	for _, f := range fields {
+		if len(f.index) == depth {
+			candidates = append(candidates, f)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+
+	var tagged []field
+	for _, f := range candidates {
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+
+	return field{}, false
+}
+
+// appendIndex returns a copy of index with i appended.
+func appendIndex(index []int, i int) []int {
+	result := make([]int, len(index)+1)
+	copy(result, index)
+	result[len(index)] = i
+	return result
+}
+
+// lessIndex orders index sequences by declaration order.
+func lessIndex(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
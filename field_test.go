@@ -0,0 +1,43 @@
+package gj
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTypeFields(t *testing.T) {
+	type inner struct {
+		A int
+		B int `json:"b"`
+		C int
+	}
+	type other struct {
+		C int
+		D int
+	}
+	type outer struct {
+		inner
+		other
+		A     int    `json:"a,omitempty"`
+		Named inner  `json:"named"`
+		Skip  int    `json:"-"`
+		Dash  int    `json:"-,"`
+		Opt   string `json:",omitempty"`
+		x     int
+	}
+
+	expected := []field{
+		{name: "A", index: []int{0, 0}, typ: reflect.TypeOf(0)},
+		{name: "b", index: []int{0, 1}, typ: reflect.TypeOf(0), tagged: true},
+		{name: "D", index: []int{1, 1}, typ: reflect.TypeOf(0)},
+		{name: "a", index: []int{2}, typ: reflect.TypeOf(0), omitEmpty: true, tagged: true},
+		{name: "named", index: []int{3}, typ: reflect.TypeOf(inner{}), tagged: true},
+		{name: "-", index: []int{5}, typ: reflect.TypeOf(0), tagged: true},
+		{name: "Opt", index: []int{6}, typ: reflect.TypeOf(""), omitEmpty: true},
+	}
+
+	got := cachedFields(reflect.TypeOf(outer{}))
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("fields wrong.\ngot=%+v\nwant=%+v", got, expected)
+	}
+}
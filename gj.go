@@ -1,25 +1,48 @@
 package gj
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
-	"strconv"
-	"strings"
+	"io"
 )
 
 type JSON struct {
 	json *jsonExpression
+	opts *options
 }
 
 // ParseString parses input and returns a JSON object.
-func ParseString(input string) (*JSON, error) {
-	l := newLexer(input)
+// The behavior of the parser can be configured with opts; see Option for the defaults.
+// If the input is malformed, the first error found is returned as a *SyntaxError.
+func ParseString(input string, opts ...Option) (*JSON, error) {
+	return parse(newLexer(input, opts...))
+}
+
+// ParseBytes parses data and returns a JSON object.
+// It behaves exactly like ParseString.
+func ParseBytes(data []byte, opts ...Option) (*JSON, error) {
+	return parse(newReaderLexer(bytes.NewReader(data), opts...))
+}
+
+// Parse parses the input read from r and returns a JSON object.
+// r is read incrementally through a buffer, so the input does not have to be read into memory first.
+// Apart from errors returned by r, which are returned as they are, it behaves exactly like ParseString.
+func Parse(r io.Reader, opts ...Option) (*JSON, error) {
+	return parse(newReaderLexer(r, opts...))
+}
+
+// parse parses the input read by l.
+func parse(l *lexer) (*JSON, error) {
 	p := newParser(l)
-	json := &JSON{json: p.parse()}
+	json := &JSON{json: p.parse(), opts: l.opts}
 
+	if l.err != nil {
+		return nil, l.err
+	}
 	err := p.getErrors()
 	if len(err) > 0 {
-		return nil, errors.New(strings.Join(err, "\n"))
+		return nil, err[0]
 	}
 
 	return json, nil
@@ -30,92 +53,271 @@ func (j *JSON) String() string {
 }
 
 // Get returns a value in the JSON using path.
+// Only the value at path is evaluated, so the cost does not depend on the size of the rest of the document.
 func (j *JSON) Get(path string) (interface{}, error) {
-	json := evalExpression(j.json.Value)
-	if len(path) == 0 {
-		return json, nil
+	exp, err := j.find(path)
+	if err != nil {
+		return nil, err
 	}
 
-	keys := strings.Split(path, ".")
-	for _, key := range keys {
-		if strings.HasPrefix(key, "[") {
-			if key == "[" || key == "[]" {
-				return nil, fmt.Errorf(`index error - "%s"`, key)
-			}
+	return j.eval(exp)
+}
 
-			index, err := strconv.ParseInt(key[1:len(key)-1], 10, 64)
-			if err != nil {
-				return nil, fmt.Errorf(`index error - "%s"`, key)
-			}
-			if index < 0 {
-				return nil, errors.New("index error - index out of bounds")
-			}
+// GetValues returns every value in the JSON using path.
+// It differs from Get only when the last element of path is a key that appears more than once in its object,
+// which is kept by DuplicateKeyKeepAll. In that case the values of all occurrences are returned in order.
+func (j *JSON) GetValues(path string) ([]interface{}, error) {
+	segs, err := parseSinglePath(path)
+	if err != nil {
+		return nil, err
+	}
+	exp, err := findSegments(j.json.Value, segs)
+	if err != nil {
+		return nil, err
+	}
+	exps := []expression{exp}
 
-			obj, ok := json.([]interface{})
-			if !ok {
-				return nil, errors.New(`index error - cannot use "[]"`)
-			}
+	if n := len(segs); n > 0 && !segs[n-1].isIndex {
+		parent, _ := findSegments(j.json.Value, segs[:n-1])
+		if obj, ok := parent.(*objectExpression); ok {
+			exps = obj.getAll(segs[n-1].key)
+		}
+	}
 
-			if index >= int64(len(obj)) {
-				return nil, errors.New("index error - index out of bounds")
-			}
+	values := make([]interface{}, 0, len(exps))
+	for _, exp := range exps {
+		value, err := j.eval(exp)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
 
-			json = obj[index]
-		} else {
-			obj, ok := json.(map[string]interface{})
-			if !ok {
-				return nil, fmt.Errorf(`key error - "%s"`, key)
-			}
+	return values, nil
+}
+
+// GetAll returns every value in the JSON selected by path, in document order.
+// Besides keys and indices, path may use * or [*] for every member or element, and ..key to find key
+// in a value and all of its descendants. Keys and indices that do not exist select nothing.
+func (j *JSON) GetAll(path string) ([]interface{}, error) {
+	segs, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
 
-			json, ok = obj[key]
-			if !ok {
-				return nil, fmt.Errorf(`key error - "%s"`, key)
+	exps := []expression{j.json.Value}
+	for _, seg := range segs {
+		var next []expression
+		for _, exp := range exps {
+			if seg.descendant {
+				next = selectDescendants(exp, seg, next)
+			} else {
+				next = selectChildren(exp, seg, next)
 			}
 		}
+		exps = next
 	}
 
-	return json, nil
+	values := make([]interface{}, 0, len(exps))
+	for _, exp := range exps {
+		value, err := j.eval(exp)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// selectChildren appends the members of the object or the elements of the array exp selected by seg to exps.
+func selectChildren(exp expression, seg pathSegment, exps []expression) []expression {
+	if !seg.wildcard {
+		if value, err := child(exp, seg); err == nil {
+			exps = append(exps, value)
+		}
+		return exps
+	}
+
+	switch exp := exp.(type) {
+	case *objectExpression:
+		for _, pair := range exp.Pairs {
+			exps = append(exps, pair.Value)
+		}
+	case *arrayExpression:
+		exps = append(exps, exp.Values...)
+	}
+	return exps
+}
+
+// selectDescendants appends the values selected by seg from exp and from each of its descendants to exps.
+func selectDescendants(exp expression, seg pathSegment, exps []expression) []expression {
+	exps = selectChildren(exp, seg, exps)
+
+	switch exp := exp.(type) {
+	case *objectExpression:
+		for _, pair := range exp.Pairs {
+			exps = selectDescendants(pair.Value, seg, exps)
+		}
+	case *arrayExpression:
+		for _, value := range exp.Values {
+			exps = selectDescendants(value, seg, exps)
+		}
+	}
+	return exps
+}
+
+// Keys returns the keys of the object at path in the order they appear in the input.
+func (j *JSON) Keys(path string) ([]string, error) {
+	obj, err := j.findObject(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(obj.Pairs))
+	for _, pair := range obj.Pairs {
+		keys = append(keys, pair.Key.Value)
+	}
+
+	return keys, nil
+}
+
+// ForEach calls fn for each member of the object at path in the order they appear in the input.
+// Iteration stops when fn returns false.
+func (j *JSON) ForEach(path string, fn func(key string, value interface{}) bool) error {
+	obj, err := j.findObject(path)
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range obj.Pairs {
+		value, err := j.eval(pair.Value)
+		if err != nil {
+			return err
+		}
+		if !fn(pair.Key.Value, value) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// findObject returns the object expression at path.
+func (j *JSON) findObject(path string) (*objectExpression, error) {
+	exp, err := j.find(path)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := exp.(*objectExpression)
+	if !ok {
+		return nil, fmt.Errorf(`type error - "%s" is not an object`, path)
+	}
+
+	return obj, nil
+}
+
+// find returns the expression at path without evaluating it.
+func (j *JSON) find(path string) (expression, error) {
+	segs, err := parseSinglePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return findSegments(j.json.Value, segs)
+}
+
+// findSegments returns the expression reached from exp by following segs.
+func findSegments(exp expression, segs []pathSegment) (expression, error) {
+	for _, seg := range segs {
+		var err error
+		exp, err = child(exp, seg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return exp, nil
+}
+
+// child returns the member of the object or the element of the array exp selected by seg.
+func child(exp expression, seg pathSegment) (expression, error) {
+	if seg.isIndex {
+		arr, ok := exp.(*arrayExpression)
+		if !ok {
+			return nil, errors.New(`index error - cannot use "[]"`)
+		}
+
+		i, ok := resolveIndex(arr, seg.index)
+		if !ok {
+			return nil, errors.New("index error - index out of bounds")
+		}
+
+		return arr.Values[i], nil
+	}
+
+	obj, ok := exp.(*objectExpression)
+	if !ok {
+		return nil, fmt.Errorf(`key error - "%s"`, seg.key)
+	}
+
+	value, ok := obj.get(seg.key)
+	if !ok {
+		return nil, fmt.Errorf(`key error - "%s"`, seg.key)
+	}
+
+	return value, nil
+}
+
+// resolveIndex returns the position in arr of the index i, which counts from the end if it is negative,
+// and whether it is within bounds.
+func resolveIndex(arr *arrayExpression, i int) (int, bool) {
+	if i < 0 {
+		i += len(arr.Values)
+	}
+	return i, i >= 0 && i < len(arr.Values)
+}
+
+// eval evaluates exp using the options the JSON was parsed with.
+func (j *JSON) eval(exp expression) (interface{}, error) {
+	return evalExpression(exp, j.opts.numbers)
 }
 
 // evalExpression recursively evaluates exp and returns it.
-func evalExpression(exp expression) interface{} {
+// Numbers are converted according to mode, which fails only for integers that overflow it.
+func evalExpression(exp expression, mode NumberMode) (interface{}, error) {
 	switch value := exp.(type) {
 	case *booleanExpression:
-		return value.Value
+		return value.Value, nil
 	case *nullExpression:
-		return value.Value
-	case *integerExpression:
-		return value.Value
-	case *floatExpression:
-		f, _ := strconv.ParseFloat(value.Value, 64)
-		return f
-	case *prefixExpression:
-		switch right := value.Right.(type) {
-		case *integerExpression:
-			return -right.Value
-		case *floatExpression:
-			f, _ := strconv.ParseFloat(right.Value, 64)
-			return -f
-		default:
-			// TODO: not implemented
-			return nil
-		}
+		return value.Value, nil
+	case *integerExpression, *floatExpression, *prefixExpression:
+		return evalNumber(value, mode)
 	case *stringExpression:
-		return value.Value
+		return value.Value, nil
 	case *objectExpression:
 		o := make(map[string]interface{})
-		for key := range value.Pairs {
-			o[key] = evalExpression(value.Pairs[key])
+		for _, pair := range value.Pairs {
+			v, err := evalExpression(pair.Value, mode)
+			if err != nil {
+				return nil, err
+			}
+			o[pair.Key.Value] = v
 		}
-		return o
+		return o, nil
 	case *arrayExpression:
 		var a []interface{}
 		for _, elem := range value.Values {
-			a = append(a, evalExpression(elem))
+			v, err := evalExpression(elem, mode)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
 		}
-		return a
+		return a, nil
 	default:
 		// TODO: not implemented
-		return nil
+		return nil, nil
 	}
 }
@@ -1,6 +1,15 @@
 package gj
 
-import "testing"
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
 
 func TestParseString(t *testing.T) {
 
@@ -9,9 +18,12 @@ func TestParseString(t *testing.T) {
 			input         string
 			expectedError string
 		}{
-			{"$", "no parse function for ILLEGAL found."},
-			{"{", "expected next token to be STRING, got EOF instead."},
-			{"{!}", "expected next token to be STRING, got ILLEGAL instead."},
+			{"$", "line 1, column 1: no parse function for ILLEGAL found."},
+			{"{", "line 1, column 2: expected next token to be STRING, got EOF instead."},
+			{"{!}", "line 1, column 2: expected next token to be STRING, got ILLEGAL instead."},
+			{`"\q"`, `line 1, column 2: invalid escape sequence "\q" in string.`},
+			{"[1, 01]", `line 1, column 5: invalid number "01": leading zeros are not allowed.`},
+			{"{\n  \"a\": 1,\n  \"b\" 2\n}", "line 3, column 7: expected next token to be COLON, got INT instead."},
 		}
 
 		for i, tt := range tests {
@@ -26,6 +38,26 @@ func TestParseString(t *testing.T) {
 		}
 	})
 
+	t.Run("ParseString SyntaxError", func(t *testing.T) {
+		input := "[1, 2,\n 3 4]"
+
+		_, err := ParseString(input)
+
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("error not *SyntaxError. got=%T.", err)
+		}
+		if syntaxErr.Offset != 10 || syntaxErr.Line != 2 || syntaxErr.Column != 4 {
+			t.Errorf("position wrong. got=%+v.", syntaxErr.Position)
+		}
+		if syntaxErr.Expected != tokRBracket || syntaxErr.Got != tokInt {
+			t.Errorf("expected/got wrong. got=%q/%q.", syntaxErr.Expected, syntaxErr.Got)
+		}
+		if syntaxErr.Snippet != " 3 4]\n   ^" {
+			t.Errorf("snippet wrong. got=%q.", syntaxErr.Snippet)
+		}
+	})
+
 }
 
 func TestGet(t *testing.T) {
@@ -51,8 +83,12 @@ func TestGet(t *testing.T) {
 			{"1", "", int64(1)},
 			{"3.14", "", 3.14},
 			{`"foo"`, "", "foo"},
+			{`"a\"b\\c\/d\ne\u00e9"`, "", "a\"b\\c/d\neé"},
 			{"-1", "", int64(-1)},
 			{"-3.14", "", -3.14},
+			{"1e10", "", 1e10},
+			{"6.02E+23", "", 6.02e23},
+			{"-1.5e-3", "", -1.5e-3},
 			{`{"foo": 2}`, "foo", int64(2)},
 			{`{"foo": {"bar": 3}}`, "foo.bar", int64(3)},
 			{"[1, 2, 3]", "[1]", int64(2)},
@@ -87,6 +123,9 @@ func TestGet(t *testing.T) {
 			case float64:
 				got, ok := val.(float64)
 				checkResult(t, val, got, want, ok)
+			case string:
+				got, ok := val.(string)
+				checkResult(t, val, got, want, ok)
 			}
 		}
 	})
@@ -132,7 +171,7 @@ func TestGet(t *testing.T) {
 			{"[0]", `index error - cannot use "[]"`},
 			{"bar.[", `index error - "["`},
 			{"bar.[]", `index error - "[]"`},
-			{"bar.[-1]", "index error - index out of bounds"},
+			{"bar.[-4]", "index error - index out of bounds"},
 			{"bar.[3]", "index error - index out of bounds"},
 			{"bar.[wow]", `index error - "[wow]"`},
 		}
@@ -151,3 +190,429 @@ func TestGet(t *testing.T) {
 	})
 
 }
+
+func TestJSONString(t *testing.T) {
+	input := `{"zebra": 1, "apple": {"y": [true, null], "x": "s"}, "mango": -2.5}`
+	expected := `{"zebra": 1, "apple": {"y": [true, null], "x": "s"}, "mango": -2.5}`
+
+	json, err := ParseString(input)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err.Error())
+	}
+
+	for i := 0; i < 10; i++ {
+		if json.String() != expected {
+			t.Fatalf("json.String() wrong. got=%q, want=%q.", json.String(), expected)
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	input := `{"zebra": 1, "apple": {"y": 2, "x": 3}, "mango": [{"b": 1, "a": 2}]}`
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"", []string{"zebra", "apple", "mango"}},
+		{"apple", []string{"y", "x"}},
+		{"mango.[0]", []string{"b", "a"}},
+	}
+
+	json, _ := ParseString(input)
+	for _, tt := range tests {
+		keys, err := json.Keys(tt.path)
+		if err != nil {
+			t.Fatalf("unexpected error - %q", err.Error())
+		}
+		if !reflect.DeepEqual(keys, tt.expected) {
+			t.Errorf("Keys(%q) wrong. got=%q, want=%q.", tt.path, keys, tt.expected)
+		}
+	}
+
+	errorTests := []struct {
+		path          string
+		expectedError string
+	}{
+		{"zebra", `type error - "zebra" is not an object`},
+		{"meh", `key error - "meh"`},
+		{"mango.[1]", "index error - index out of bounds"},
+	}
+
+	for i, tt := range errorTests {
+		_, err := json.Keys(tt.path)
+		if err == nil {
+			t.Fatalf("[test %d] error expected, got none.", i)
+		}
+		if err.Error() != tt.expectedError {
+			t.Errorf("[test %d] unexpected error - got=%q, want=%q.", i, err.Error(), tt.expectedError)
+		}
+	}
+}
+
+func TestForEach(t *testing.T) {
+	input := `{"c": 1, "a": "two", "b": [3]}`
+
+	json, _ := ParseString(input)
+
+	var keys []string
+	var values []interface{}
+	err := json.ForEach("", func(key string, value interface{}) bool {
+		keys = append(keys, key)
+		values = append(values, value)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err.Error())
+	}
+	if !reflect.DeepEqual(keys, []string{"c", "a", "b"}) {
+		t.Errorf("keys wrong. got=%q.", keys)
+	}
+	if !reflect.DeepEqual(values, []interface{}{int64(1), "two", []interface{}{int64(3)}}) {
+		t.Errorf("values wrong. got=%v.", values)
+	}
+
+	count := 0
+	json.ForEach("", func(key string, value interface{}) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Errorf("ForEach did not stop. called %d times.", count)
+	}
+}
+
+func TestGetAll(t *testing.T) {
+	input := `{
+	"store": {
+		"book": [
+			{"author": "Rees", "price": 8.95},
+			{"author": "Waugh", "price": 12.99, "tags": {"author": "none"}}
+		],
+		"bicycle": {"color": "red", "price": 399}
+	},
+	"*": "star"
+}`
+	json, err := ParseString(input)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err.Error())
+	}
+
+	tests := []struct {
+		path     string
+		expected []interface{}
+	}{
+		{"store.book.[*].author", []interface{}{"Rees", "Waugh"}},
+		{"store.book.*.author", []interface{}{"Rees", "Waugh"}},
+		{"store.book.[-1].author", []interface{}{"Waugh"}},
+		{"store.book.[-3].author", []interface{}{}},
+		{"store.bicycle.*", []interface{}{"red", int64(399)}},
+		{"..author", []interface{}{"Rees", "Waugh", "none"}},
+		{"store..price", []interface{}{8.95, 12.99, int64(399)}},
+		{"store.book..author", []interface{}{"Rees", "Waugh", "none"}},
+		{"..book.[0].price", []interface{}{8.95}},
+		{"..missing", []interface{}{}},
+		{"store.bicycle.color.*", []interface{}{}},
+		{`\*`, []interface{}{"star"}},
+		{"store.bicycle.color", []interface{}{"red"}},
+	}
+
+	for i, tt := range tests {
+		values, err := json.GetAll(tt.path)
+		if err != nil {
+			t.Errorf("[test %d] unexpected error - %q", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(values, tt.expected) {
+			t.Errorf("[test %d] GetAll(%q) wrong. got=%v, want=%v.", i, tt.path, values, tt.expected)
+		}
+	}
+
+	if all, _ := json.GetAll("..*"); len(all) != 14 {
+		t.Errorf("number of descendants wrong. got=%d, want=%d.", len(all), 14)
+	}
+	if got, err := json.Get("store.book.[-1].price"); err != nil || got != 12.99 {
+		t.Errorf("Get with negative index wrong. got=%v, err=%v.", got, err)
+	}
+	if _, err := json.Get("store.*"); err == nil || err.Error() != `path error - "store.*" can select more than one value` {
+		t.Errorf("unexpected error - %v", err)
+	}
+	if _, err := json.GetAll("store..."); err == nil {
+		t.Errorf("error expected, got none.")
+	}
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	input := `{"a": 1, "b": {"c": 2}, "a": 3, "a": 4}`
+
+	t.Run("policies", func(t *testing.T) {
+		tests := []struct {
+			policy         DuplicateKeyPolicy
+			expectedValue  int64
+			expectedString string
+		}{
+			{DuplicateKeyLastWins, 4, `{"a": 4, "b": {"c": 2}}`},
+			{DuplicateKeyFirstWins, 1, `{"a": 1, "b": {"c": 2}}`},
+			{DuplicateKeyKeepAll, 4, `{"a": 1, "b": {"c": 2}, "a": 3, "a": 4}`},
+		}
+
+		for i, tt := range tests {
+			json, err := ParseString(input, WithDuplicateKeys(tt.policy))
+			if err != nil {
+				t.Fatalf("[test %d] unexpected error - %q", i, err.Error())
+			}
+
+			val, err := json.Get("a")
+			if err != nil {
+				t.Fatalf("[test %d] unexpected error - %q", i, err.Error())
+			}
+			if val != tt.expectedValue {
+				t.Errorf("[test %d] Get result wrong. got=%v, want=%v", i, val, tt.expectedValue)
+			}
+			if json.String() != tt.expectedString {
+				t.Errorf("[test %d] json.String() wrong. got=%q, want=%q.", i, json.String(), tt.expectedString)
+			}
+		}
+	})
+
+	t.Run("default is last wins", func(t *testing.T) {
+		json, _ := ParseString(input)
+		val, _ := json.Get("a")
+		if val != int64(4) {
+			t.Errorf("Get result wrong. got=%v, want=%v", val, 4)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		_, err := ParseString("{\"a\": 1,\n \"a\": 2}", WithDuplicateKeys(DuplicateKeyReject))
+		if err == nil {
+			t.Fatalf("error expected, got none.")
+		}
+
+		expected := `line 2, column 2: duplicate key "a" (first defined at line 1, column 2).`
+		if err.Error() != expected {
+			t.Errorf("unexpected error - got=%q, want=%q.", err.Error(), expected)
+		}
+
+		var dupErr *DuplicateKeyError
+		if !errors.As(err, &dupErr) {
+			t.Fatalf("error does not wrap *DuplicateKeyError. got=%T.", err)
+		}
+		if dupErr.Key != "a" {
+			t.Errorf("dupErr.Key wrong. got=%q.", dupErr.Key)
+		}
+		if dupErr.First != (Position{Offset: 1, Line: 1, Column: 2}) {
+			t.Errorf("dupErr.First wrong. got=%+v.", dupErr.First)
+		}
+		if dupErr.Second != (Position{Offset: 10, Line: 2, Column: 2}) {
+			t.Errorf("dupErr.Second wrong. got=%+v.", dupErr.Second)
+		}
+	})
+
+	t.Run("GetValues", func(t *testing.T) {
+		json, _ := ParseString(`{"a": 1, "b": {"c": 2, "c": [3]}, "a": 4}`, WithDuplicateKeys(DuplicateKeyKeepAll))
+
+		tests := []struct {
+			path     string
+			expected []interface{}
+		}{
+			{"a", []interface{}{int64(1), int64(4)}},
+			{"b.c", []interface{}{int64(2), []interface{}{int64(3)}}},
+			{"b.c.[0]", []interface{}{int64(3)}},
+		}
+
+		for _, tt := range tests {
+			values, err := json.GetValues(tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error - %q", err.Error())
+			}
+			if !reflect.DeepEqual(values, tt.expected) {
+				t.Errorf("GetValues(%q) wrong. got=%v, want=%v.", tt.path, values, tt.expected)
+			}
+		}
+
+		if _, err := json.GetValues("b.d"); err == nil || err.Error() != `key error - "d"` {
+			t.Errorf("unexpected error - %v", err)
+		}
+	})
+}
+
+func TestParse(t *testing.T) {
+	inputs := []string{
+		`{"name": "John", "age": 27, "tags": ["a", "b\n"], "spouse": null}`,
+		"[1, -2.5e3, true, false, \"\\u00e9\\ud83d\\ude00\"]",
+		"{\n  \"a\": 1,\n  \"b\" 2\n}",
+		`[1, 01]`,
+		`{"a": "unterminated`,
+		`[1, 2,`,
+		``,
+	}
+
+	for i, input := range inputs {
+		expected, expectedErr := ParseString(input)
+
+		readers := map[string]func() (*JSON, error){
+			"Parse": func() (*JSON, error) {
+				return Parse(iotest.OneByteReader(strings.NewReader(input)))
+			},
+			"ParseBytes": func() (*JSON, error) {
+				return ParseBytes([]byte(input))
+			},
+		}
+
+		for name, parse := range readers {
+			json, err := parse()
+
+			if expectedErr != nil {
+				if err == nil {
+					t.Fatalf("[test %d] %s - error expected, got none.", i, name)
+				}
+				if err.Error() != expectedErr.Error() {
+					t.Errorf("[test %d] %s - unexpected error - got=%q, want=%q.", i, name, err.Error(), expectedErr.Error())
+				}
+				if err.(*SyntaxError).Position != expectedErr.(*SyntaxError).Position {
+					t.Errorf("[test %d] %s - position wrong. got=%+v, want=%+v.", i, name, err.(*SyntaxError).Position, expectedErr.(*SyntaxError).Position)
+				}
+				continue
+			}
+
+			if err != nil {
+				t.Fatalf("[test %d] %s - unexpected error - %q", i, name, err.Error())
+			}
+			if json.String() != expected.String() {
+				t.Errorf("[test %d] %s - json.String() wrong. got=%q, want=%q.", i, name, json.String(), expected.String())
+			}
+		}
+	}
+}
+
+func TestParseLargeInput(t *testing.T) {
+	const n = 50000
+
+	r, pw := io.Pipe()
+	go func() {
+		w := bufio.NewWriter(pw)
+		w.WriteString("[")
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				w.WriteString(",\n")
+			}
+			fmt.Fprintf(w, `{"id": %d, "name": "item %d"}`, i, i)
+		}
+		w.WriteString("]")
+		w.Flush()
+		pw.Close()
+	}()
+
+	json, err := Parse(r)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err.Error())
+	}
+
+	val, err := json.Get(fmt.Sprintf("[%d].name", n-1))
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err.Error())
+	}
+	if val != fmt.Sprintf("item %d", n-1) {
+		t.Errorf("Get result wrong. got=%v.", val)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Run("reader error", func(t *testing.T) {
+		readErr := errors.New("connection reset")
+		r := io.MultiReader(strings.NewReader(`{"a": [1, 2`), &errorReader{err: readErr})
+
+		_, err := Parse(r)
+		if err != readErr {
+			t.Errorf("unexpected error - got=%v, want=%v.", err, readErr)
+		}
+	})
+
+	t.Run("error position in long input", func(t *testing.T) {
+		input := "[" + strings.Repeat("1, ", 50000) + "\n  x]"
+
+		_, err := Parse(strings.NewReader(input))
+
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("error not *SyntaxError. got=%T.", err)
+		}
+		if syntaxErr.Offset != 150004 || syntaxErr.Line != 2 || syntaxErr.Column != 3 {
+			t.Errorf("position wrong. got=%+v.", syntaxErr.Position)
+		}
+		if syntaxErr.Snippet != "  x]\n  ^" {
+			t.Errorf("snippet wrong. got=%q.", syntaxErr.Snippet)
+		}
+	})
+
+	t.Run("max input size", func(t *testing.T) {
+		r := io.MultiReader(strings.NewReader("[1, 2, 3]"), &errorReader{err: errors.New("must not be read")})
+
+		_, err := Parse(r, WithMaxInputSize(4))
+		expected := "line 1, column 5: input exceeds maximum size of 4 bytes."
+		if err == nil || err.Error() != expected {
+			t.Errorf("unexpected error - got=%v, want=%q.", err, expected)
+		}
+	})
+}
+
+type errorReader struct {
+	err error
+}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+// benchmarkDocument returns an inventory of n items.
+func benchmarkDocument(n int) string {
+	var b strings.Builder
+	b.WriteString(`{"inventory": [`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, `{"id": %d, "name": "item %d", "price": %d.5, "tags": ["a", "b"], "stock": {"warehouse": %d}}`, i, i, i, i%7)
+	}
+	b.WriteString(`], "updated": "2020-01-01"}`)
+	return b.String()
+}
+
+func BenchmarkGet(b *testing.B) {
+	for _, n := range []int{100, 10000} {
+		json, err := ParseString(benchmarkDocument(n))
+		if err != nil {
+			b.Fatalf("unexpected error - %q", err.Error())
+		}
+		path := fmt.Sprintf("inventory.[%d].stock.warehouse", n/2)
+
+		b.Run(fmt.Sprintf("items=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := json.Get(path); err != nil {
+					b.Fatalf("unexpected error - %q", err.Error())
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGetEvalRoot measures the former implementation of Get, which evaluated the whole document
+// before following the path, for comparison with BenchmarkGet.
+func BenchmarkGetEvalRoot(b *testing.B) {
+	for _, n := range []int{100, 10000} {
+		json, err := ParseString(benchmarkDocument(n))
+		if err != nil {
+			b.Fatalf("unexpected error - %q", err.Error())
+		}
+
+		b.Run(fmt.Sprintf("items=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				value, _ := json.eval(json.json.Value)
+				root := value.(map[string]interface{})
+				item := root["inventory"].([]interface{})[n/2].(map[string]interface{})
+				_ = item["stock"].(map[string]interface{})["warehouse"]
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package gj
+
+import (
+	"io"
+	"strings"
+)
+
+// Handler receives the events reported by Walk while it reads the input.
+// Each event carries the position of the token it was triggered by.
+// If a method returns an error, Walk stops reading and returns that error.
+type Handler interface {
+	// OnObjectStart is called at the opening brace of an object.
+	OnObjectStart(pos Position) error
+	// OnObjectEnd is called at the closing brace of an object.
+	OnObjectEnd(pos Position) error
+	// OnArrayStart is called at the opening bracket of an array.
+	OnArrayStart(pos Position) error
+	// OnArrayEnd is called at the closing bracket of an array.
+	OnArrayEnd(pos Position) error
+	// OnKey is called for the key of each object member, before the events of its value.
+	OnKey(key string, pos Position) error
+	// OnValue is called for each string, number, boolean and null.
+	// Numbers are converted according to the NumberMode.
+	OnValue(value interface{}, pos Position) error
+	// OnEnd is called once after the top-level value, at the position of the end of the input.
+	OnEnd(pos Position) error
+}
+
+// BaseHandler implements every method of Handler by doing nothing.
+// It can be embedded in a handler that only needs some of the events.
+type BaseHandler struct{}
+
+func (BaseHandler) OnObjectStart(pos Position) error              { return nil }
+func (BaseHandler) OnObjectEnd(pos Position) error                { return nil }
+func (BaseHandler) OnArrayStart(pos Position) error               { return nil }
+func (BaseHandler) OnArrayEnd(pos Position) error                 { return nil }
+func (BaseHandler) OnKey(key string, pos Position) error          { return nil }
+func (BaseHandler) OnValue(value interface{}, pos Position) error { return nil }
+func (BaseHandler) OnEnd(pos Position) error                      { return nil }
+
+// Walk reads the input from r and reports its structure to h as a series of events,
+// without building the document in memory. It returns the first syntax error as a *SyntaxError,
+// or the first error returned by h. The events before a syntax error are still reported.
+//
+// Repeated keys are reported as they appear, unless the parser is configured with DuplicateKeyReject.
+func Walk(r io.Reader, h Handler, opts ...Option) error {
+	l := newReaderLexer(r, opts...)
+	p := newParser(l)
+
+	err := p.walk(h)
+	if l.err != nil {
+		return l.err
+	}
+
+	return err
+}
+
+// WalkString is like Walk, but reads the input from a string.
+func WalkString(input string, h Handler, opts ...Option) error {
+	return Walk(strings.NewReader(input), h, opts...)
+}
+
+// walk reports the events of the whole input to h.
+func (p *parser) walk(h Handler) error {
+	if err := p.walkExpression(h); err != nil {
+		return err
+	}
+
+	p.checkEnd()
+	if err := p.firstError(); err != nil {
+		return err
+	}
+
+	return h.OnEnd(p.peekToken.Pos)
+}
+
+// walkExpression reports the events of the value starting at curToken to h.
+func (p *parser) walkExpression(h Handler) error {
+	if err := p.firstError(); err != nil {
+		return err
+	}
+
+	switch p.curToken.Type {
+	case tokLBrace:
+		return p.walkObject(h)
+	case tokLBracket:
+		return p.walkArray(h)
+	}
+
+	pos := p.curToken.Pos
+	exp := p.parseExpression()
+	if err := p.firstError(); err != nil {
+		return err
+	}
+
+	value, err := evalExpression(exp, p.opts.numbers)
+	if err != nil {
+		return err
+	}
+	return h.OnValue(value, pos)
+}
+
+// walkObject reports the events of the object starting at curToken to h.
+func (p *parser) walkObject(h Handler) error {
+	if !p.enter() {
+		return p.firstError()
+	}
+	defer p.leave()
+
+	if err := h.OnObjectStart(p.curToken.Pos); err != nil {
+		return err
+	}
+
+	// Only the keys are kept, and only when repeated keys have to be rejected.
+	var keys map[string]*stringExpression
+	if p.opts.duplicateKeys == DuplicateKeyReject {
+		keys = make(map[string]*stringExpression)
+	}
+
+	for !p.peekTokenIs(tokRBrace) {
+		if !p.expectPeek(tokString) {
+			return p.firstError()
+		}
+
+		key := p.parseString().(*stringExpression)
+		if keys != nil {
+			if first, ok := keys[key.Value]; ok {
+				p.duplicateKeyError(first, key)
+				return p.firstError()
+			}
+			keys[key.Value] = key
+		}
+		if err := p.firstError(); err != nil {
+			return err
+		}
+		if err := h.OnKey(key.Value, key.Token.Pos); err != nil {
+			return err
+		}
+
+		if !p.expectPeek(tokColon) {
+			return p.firstError()
+		}
+
+		p.nextToken()
+		if err := p.walkExpression(h); err != nil {
+			return err
+		}
+
+		if !p.peekTokenIs(tokRBrace) && !p.expectPeek(tokComma) {
+			return p.firstError()
+		}
+		if p.curTokenIs(tokComma) && p.peekTokenIs(tokRBrace) && p.opts.mode == ModeStrict {
+			p.peekError(tokString)
+			return p.firstError()
+		}
+	}
+
+	if !p.expectPeek(tokRBrace) {
+		return p.firstError()
+	}
+	if err := p.firstError(); err != nil {
+		return err
+	}
+
+	return h.OnObjectEnd(p.curToken.Pos)
+}
+
+// walkArray reports the events of the array starting at curToken to h.
+func (p *parser) walkArray(h Handler) error {
+	if !p.enter() {
+		return p.firstError()
+	}
+	defer p.leave()
+
+	if err := h.OnArrayStart(p.curToken.Pos); err != nil {
+		return err
+	}
+
+	if !p.peekTokenIs(tokRBracket) {
+		p.nextToken()
+		if err := p.walkExpression(h); err != nil {
+			return err
+		}
+
+		for p.peekTokenIs(tokComma) {
+			p.nextToken()
+			if p.peekTokenIs(tokRBracket) && p.opts.mode == ModeLenient {
+				break
+			}
+			p.nextToken()
+			if err := p.walkExpression(h); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !p.expectPeek(tokRBracket) {
+		return p.firstError()
+	}
+	if err := p.firstError(); err != nil {
+		return err
+	}
+
+	return h.OnArrayEnd(p.curToken.Pos)
+}
+
+// firstError returns the first syntax error recorded so far, or nil if there is none.
+func (p *parser) firstError() error {
+	if len(p.errors) == 0 {
+		return nil
+	}
+	return p.errors[0]
+}
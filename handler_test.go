@@ -0,0 +1,128 @@
+package gj
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordingHandler records every event as a string.
+type recordingHandler struct {
+	events []string
+	stopAt string
+}
+
+func (h *recordingHandler) record(event string, pos Position) error {
+	h.events = append(h.events, fmt.Sprintf("%s@%d:%d", event, pos.Line, pos.Column))
+	if event == h.stopAt {
+		return errStop
+	}
+	return nil
+}
+
+var errStop = errors.New("stop")
+
+func (h *recordingHandler) OnObjectStart(pos Position) error { return h.record("{", pos) }
+func (h *recordingHandler) OnObjectEnd(pos Position) error   { return h.record("}", pos) }
+func (h *recordingHandler) OnArrayStart(pos Position) error  { return h.record("[", pos) }
+func (h *recordingHandler) OnArrayEnd(pos Position) error    { return h.record("]", pos) }
+func (h *recordingHandler) OnKey(key string, pos Position) error {
+	return h.record("key "+key, pos)
+}
+func (h *recordingHandler) OnValue(value interface{}, pos Position) error {
+	return h.record(fmt.Sprintf("value %T %v", value, value), pos)
+}
+func (h *recordingHandler) OnEnd(pos Position) error { return h.record("end", pos) }
+
+func TestWalk(t *testing.T) {
+	input := "{\n  \"name\": \"John\",\n  \"age\": -27,\n  \"items\": [1.5, true, null, {}],\n  \"empty\": []\n}"
+
+	expected := []string{
+		"{@1:1",
+		"key name@2:3", "value string John@2:11",
+		"key age@3:3", "value int64 -27@3:10",
+		"key items@4:3", "[@4:12", "value float64 1.5@4:13", "value bool true@4:18", "value <nil> <nil>@4:24", "{@4:30", "}@4:31", "]@4:32",
+		"key empty@5:3", "[@5:12", "]@5:13",
+		"}@6:1",
+		"end@6:2",
+	}
+
+	h := &recordingHandler{}
+	if err := WalkString(input, h); err != nil {
+		t.Fatalf("unexpected error - %q", err.Error())
+	}
+	if !reflect.DeepEqual(h.events, expected) {
+		t.Errorf("events wrong.\ngot= %q\nwant=%q", h.events, expected)
+	}
+}
+
+func TestWalkStop(t *testing.T) {
+	h := &recordingHandler{stopAt: "value int64 2"}
+
+	err := Walk(strings.NewReader("[1, 2, 3]"), h)
+	if err != errStop {
+		t.Fatalf("unexpected error - got=%v, want=%v.", err, errStop)
+	}
+
+	expected := []string{"[@1:1", "value int64 1@1:2", "value int64 2@1:5"}
+	if !reflect.DeepEqual(h.events, expected) {
+		t.Errorf("events wrong.\ngot= %q\nwant=%q", h.events, expected)
+	}
+}
+
+func TestWalkErrors(t *testing.T) {
+	tests := []struct {
+		input          string
+		opts           []Option
+		expectedError  string
+		expectedEvents int
+	}{
+		{`[1, 2 3]`, nil, "line 1, column 7: expected next token to be ], got INT instead.", 3},
+		{`{"a": 1, "b" 2}`, nil, "line 1, column 14: expected next token to be COLON, got INT instead.", 4},
+		{`[1, "\x"]`, nil, `line 1, column 6: invalid escape sequence "\x" in string.`, 2},
+		{`[1, 2`, nil, "line 1, column 6: expected next token to be ], got EOF instead.", 3},
+		{`{"a": 1, "a": 2}`, []Option{WithDuplicateKeys(DuplicateKeyReject)}, `line 1, column 10: duplicate key "a" (first defined at line 1, column 2).`, 3},
+		{`[[1]]`, []Option{WithMaxDepth(1)}, "line 1, column 2: maximum nesting depth of 1 exceeded.", 1},
+		{`[1] [2]`, []Option{WithMode(ModeStrict)}, "line 1, column 5: unexpected [ after top-level value.", 3},
+	}
+
+	for i, tt := range tests {
+		h := &recordingHandler{}
+		err := WalkString(tt.input, h, tt.opts...)
+
+		if err == nil {
+			t.Fatalf("[test %d] error expected, got none.", i)
+		}
+		if err.Error() != tt.expectedError {
+			t.Errorf("[test %d] unexpected error - got=%q, want=%q.", i, err.Error(), tt.expectedError)
+		}
+		if len(h.events) != tt.expectedEvents {
+			t.Errorf("[test %d] number of events wrong. got=%q.", i, h.events)
+		}
+	}
+}
+
+func TestBaseHandler(t *testing.T) {
+	var count int
+	h := &countingHandler{count: &count}
+
+	if err := WalkString(`{"a": [1, 2, {"b": 3}]}`, h); err != nil {
+		t.Fatalf("unexpected error - %q", err.Error())
+	}
+	if count != 3 {
+		t.Errorf("count wrong. got=%d.", count)
+	}
+}
+
+// countingHandler counts values and ignores every other event.
+type countingHandler struct {
+	BaseHandler
+	count *int
+}
+
+func (h *countingHandler) OnValue(value interface{}, pos Position) error {
+	*h.count++
+	return nil
+}
@@ -1,31 +1,74 @@
 package gj
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
 	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 const eof = 0
 
+// snippetContext is the maximum number of bytes shown on either side of an error in a snippet.
+const snippetContext = 40
+
+// snippetHistory is the number of most recently read bytes kept for building snippets.
+const snippetHistory = 64 * 1024
+
 type lexer struct {
-	input        string
+	r            *bufio.Reader
 	position     int
 	readPosition int
 	ch           byte
+	line         int
+	column       int
+	errors       []*SyntaxError
+	attached     int // number of errors already attached to a token
+	opts         *options
+
+	atEOF        bool  // the end of the input has been reached
+	err          error // error returned by r, other than io.EOF
+	recording    bool  // whether consumed characters are collected into literal
+	literal      []byte
+	history      []byte // the most recently read bytes, starting at offset historyStart
+	historyStart int
+}
+
+// newLexer returns a lexer reading from input.
+// The options are shared with the parser reading from the lexer.
+func newLexer(input string, opts ...Option) *lexer {
+	return newReaderLexer(strings.NewReader(input), opts...)
 }
 
-// newLexer returns a lexer object.
-func newLexer(input string) *lexer {
-	l := &lexer{input: input}
+// newReaderLexer returns a lexer reading incrementally from r.
+func newReaderLexer(r io.Reader, opts ...Option) *lexer {
+	l := &lexer{r: bufio.NewReader(r), line: 1, opts: newOptions(opts)}
 	l.readChar()
 	return l
 }
 
 // nextToken returns the next token.
+// The first error found while reading the token, if any, is attached to it.
 func (l *lexer) nextToken() token {
+	tok := l.scanToken()
+	if l.attached < len(l.errors) {
+		tok.Err = l.errors[l.attached]
+		l.attached = len(l.errors)
+	}
+	return tok
+}
+
+// scanToken reads the next token from the input.
+func (l *lexer) scanToken() token {
 	var tok token
 
 	l.skipWhitespace()
 
+	pos := l.pos()
+
 	switch l.ch {
 	case ',':
 		tok = newToken(tokComma, l.ch)
@@ -40,10 +83,20 @@ func (l *lexer) nextToken() token {
 	case ']':
 		tok = newToken(tokRBracket, l.ch)
 	case '"':
-		tok.Type = tokString
-		tok.Literal = l.readString()
+		str, ok := l.readString(pos)
+		if ok {
+			tok.Type = tokString
+		} else {
+			tok.Type = tokIllegal
+		}
+		tok.Literal = str
 	case '-':
-		tok = newToken(tokMinus, l.ch)
+		if isDigit(l.peekChar()) {
+			tok = newToken(tokMinus, l.ch)
+		} else {
+			l.addError(pos, "expected digit after '-'.")
+			tok = newToken(tokIllegal, l.ch)
+		}
 	case eof:
 		tok.Literal = ""
 		tok.Type = tokEOF
@@ -51,14 +104,20 @@ func (l *lexer) nextToken() token {
 		if isLetter(l.ch) {
 			tok.Literal = l.readKeyword()
 			tok.Type = lookupKeyword(tok.Literal)
+			tok.Pos = pos
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			if strings.Contains(tok.Literal, ".") {
+			literal, isFloat, ok := l.readNumber(pos)
+			tok.Literal = literal
+			switch {
+			case !ok:
+				tok.Type = tokIllegal
+			case isFloat:
 				tok.Type = tokFloat
-			} else {
+			default:
 				tok.Type = tokInt
 			}
+			tok.Pos = pos
 			return tok
 		} else {
 			tok = newToken(tokIllegal, l.ch)
@@ -67,82 +126,372 @@ func (l *lexer) nextToken() token {
 
 	l.readChar()
 
+	tok.Pos = pos
 	return tok
 }
 
-// skipWhitespace kips whitespace characters.
+// skipWhitespace skips whitespace characters, and comments if they are allowed.
 func (l *lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+	for {
+		switch {
+		case l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r':
+			l.readChar()
+		case l.ch == '/' && l.opts.comments:
+			if !l.skipComment() {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// skipComment skips a line or block comment starting at the current character.
+// It returns false if the current character does not start a comment.
+func (l *lexer) skipComment() bool {
+	start := l.pos()
+
+	switch l.peekChar() {
+	case '/':
+		for l.ch != '\n' && l.ch != eof {
+			l.readChar()
+		}
+	case '*':
+		l.readChar()
+		l.readChar()
+		for l.ch != '*' || l.peekChar() != '/' {
+			if l.ch == eof {
+				l.addError(start, "unterminated comment.")
+				return true
+			}
+			l.readChar()
+		}
 		l.readChar()
+		l.readChar()
+	default:
+		return false
 	}
+
+	return true
 }
 
-// TODO: Support Unicode
-// readChar reads the next character and advances the position in the input string.
+// readChar reads the next character and advances the position in the input.
+// The line and column are updated as well; UTF-8 continuation bytes do not advance the column.
 func (l *lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		l.ch = eof
-	} else {
-		l.ch = l.input[l.readPosition]
+	if l.recording {
+		l.literal = append(l.literal, l.ch)
+	}
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.ch = l.readByte()
+	if !utf8.RuneStart(l.ch) {
+		l.column--
 	}
+	l.column++
 	l.position = l.readPosition
 	l.readPosition++
+
+	if l.opts.maxInputSize > 0 && l.position == l.opts.maxInputSize && l.peekByte() {
+		l.addError(l.pos(), fmt.Sprintf("input exceeds maximum size of %d bytes.", l.opts.maxInputSize))
+	}
+}
+
+// readByte returns the byte at readPosition, or eof at the end of the input.
+// Bytes beyond the maximum input size are never read.
+func (l *lexer) readByte() byte {
+	if l.atEOF {
+		return eof
+	}
+	if l.opts.maxInputSize > 0 && l.readPosition >= l.opts.maxInputSize {
+		return eof
+	}
+
+	b, err := l.r.ReadByte()
+	if err != nil {
+		if err != io.EOF {
+			l.err = err
+		}
+		l.atEOF = true
+		return eof
+	}
+
+	l.history = append(l.history, b)
+	if len(l.history) >= 2*snippetHistory {
+		drop := len(l.history) - snippetHistory
+		l.history = l.history[:copy(l.history, l.history[drop:])]
+		l.historyStart += drop
+	}
+
+	return b
+}
+
+// peekByte returns true if there is at least one more byte to read from the underlying reader.
+func (l *lexer) peekByte() bool {
+	if l.atEOF {
+		return false
+	}
+	_, err := l.r.Peek(1)
+	return err == nil
+}
+
+// pos returns the position of the current character.
+func (l *lexer) pos() Position {
+	return Position{Offset: l.position, Line: l.line, Column: l.column}
 }
 
 func (l *lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+	if l.atEOF || l.opts.maxInputSize > 0 && l.readPosition >= l.opts.maxInputSize {
+		return eof
+	}
+	b, err := l.r.Peek(1)
+	if err != nil {
 		return eof
-	} else {
-		return l.input[l.readPosition]
 	}
+	return b[0]
+}
+
+// startLiteral starts collecting the characters consumed from the current one on.
+func (l *lexer) startLiteral() {
+	l.literal = l.literal[:0]
+	l.recording = true
+}
+
+// endLiteral stops collecting characters and returns the ones consumed since startLiteral.
+func (l *lexer) endLiteral() string {
+	l.recording = false
+	return string(l.literal)
 }
 
-// readString returns a series of characters surrounded by double quotes.
+// readString returns the decoded contents of a string surrounded by double quotes.
 // It advances the position until it encounters either a closing double quote or the end of the input.
-func (l *lexer) readString() string {
-	start := l.position + 1
+// Escape sequences are decoded as defined in RFC 8259. If the string is unterminated or contains an
+// invalid escape sequence, an error is recorded and ok is false. start is the position of the opening quote.
+// In ModeStrict, unescaped control characters and invalid UTF-8 are errors as well.
+func (l *lexer) readString(start Position) (str string, ok bool) {
+	var out strings.Builder
+	ok = true
+	strict := l.opts.mode == ModeStrict
+	tooLong := false
 
 	for {
+		if !tooLong && l.opts.maxStringLength > 0 && out.Len() > l.opts.maxStringLength {
+			l.addError(start, fmt.Sprintf("string exceeds maximum length of %d bytes.", l.opts.maxStringLength))
+			ok = false
+			tooLong = true
+		}
+		if tooLong {
+			// Keep reading up to the closing quote, but stop collecting the contents.
+			out.Reset()
+		}
+
 		l.readChar()
-		if l.ch == '"' || l.ch == eof {
+		if l.ch == '"' {
 			break
 		}
-		if l.ch == '\\' && l.peekChar() == '"' {
-			l.readChar()
+		if l.ch == eof {
+			l.addError(start, "unterminated string.")
+			return out.String(), false
+		}
+		if strict && l.ch < 0x20 {
+			l.addError(l.pos(), fmt.Sprintf("invalid control character %q in string.", l.ch))
+			ok = false
+			continue
+		}
+		if l.ch != '\\' {
+			out.WriteByte(l.ch)
+			continue
+		}
+
+		escape := l.pos()
+		l.readChar()
+		switch l.ch {
+		case '"', '\\', '/':
+			out.WriteByte(l.ch)
+		case 'b':
+			out.WriteByte('\b')
+		case 'f':
+			out.WriteByte('\f')
+		case 'n':
+			out.WriteByte('\n')
+		case 'r':
+			out.WriteByte('\r')
+		case 't':
+			out.WriteByte('\t')
+		case 'u':
+			r, valid := l.readUnicodeEscape(escape)
+			if !valid {
+				ok = false
+				continue
+			}
+			out.WriteRune(r)
+		case eof:
+			l.addError(start, "unterminated string.")
+			return out.String(), false
+		default:
+			l.addError(escape, fmt.Sprintf(`invalid escape sequence "\%c" in string.`, l.ch))
+			ok = false
 		}
 	}
 
-	result := strings.Replace(l.input[start:l.position], `\"`, `"`, -1)
+	if ok && strict && !utf8.ValidString(out.String()) {
+		l.addError(start, "invalid UTF-8 in string.")
+		ok = false
+	}
+
+	return out.String(), ok
+}
+
+// readUnicodeEscape decodes the hexadecimal digits following "\u", recording an error at escape
+// if they are invalid or form an unpaired surrogate.
+func (l *lexer) readUnicodeEscape(escape Position) (rune, bool) {
+	r, reason := decodeUnicodeEscape(l.peekChar, l.readChar)
+	switch reason {
+	case "":
+		return r, true
+	case unpairedSurrogate:
+		l.addError(escape, fmt.Sprintf("unpaired surrogate \\u%04x in string.", r))
+	default:
+		l.addError(escape, reason+" in string.")
+	}
+	return utf8.RuneError, false
+}
+
+const (
+	invalidUnicodeEscape = "invalid unicode escape sequence"
+	unpairedSurrogate    = "unpaired surrogate"
+)
+
+// decodeUnicodeEscape decodes the hexadecimal digits following "\u", shared by the parsers of JSON,
+// queries and programs. peek returns the next byte of the input, or 0 at its end, and next advances past it.
+// A high surrogate must be immediately followed by an escaped low surrogate, and the pair is
+// combined into a single rune. On failure, reason is invalidUnicodeEscape, or unpairedSurrogate
+// with the surrogate as r.
+func decodeUnicodeEscape(peek func() byte, next func()) (r rune, reason string) {
+	r, ok := decodeHex4(peek, next)
+	if !ok {
+		return utf8.RuneError, invalidUnicodeEscape
+	}
+	if !utf16.IsSurrogate(r) {
+		return r, ""
+	}
+	if r >= 0xdc00 || peek() != '\\' {
+		return r, unpairedSurrogate
+	}
+	next()
+	if peek() != 'u' {
+		return r, unpairedSurrogate
+	}
+	next()
+
+	r2, ok := decodeHex4(peek, next)
+	if !ok {
+		return utf8.RuneError, invalidUnicodeEscape
+	}
+	if combined := utf16.DecodeRune(r, r2); combined != utf8.RuneError {
+		return combined, ""
+	}
+	return r, unpairedSurrogate
+}
 
-	return result
+// decodeHex4 reads four hexadecimal digits and returns their value.
+func decodeHex4(peek func() byte, next func()) (rune, bool) {
+	var r rune
+	for i := 0; i < 4; i++ {
+		ch := peek()
+		var v byte
+		switch {
+		case '0' <= ch && ch <= '9':
+			v = ch - '0'
+		case 'a' <= ch && ch <= 'f':
+			v = ch - 'a' + 10
+		case 'A' <= ch && ch <= 'F':
+			v = ch - 'A' + 10
+		default:
+			return utf8.RuneError, false
+		}
+		next()
+		r = r<<4 | rune(v)
+	}
+	return r, true
 }
 
-// readNumber returns a number as a string.
-// It advances the position until it encounters a non-digit character.
-func (l *lexer) readNumber() string {
-	start := l.position
-	readDecimalPoint := false
+// readNumber returns a number as a string, following the number grammar of RFC 8259:
+// an integer part without leading zeros, an optional fraction and an optional exponent.
+// isFloat reports whether a fraction or an exponent was read. If the number is malformed,
+// an error is recorded at start and ok is false.
+func (l *lexer) readNumber(start Position) (literal string, isFloat bool, ok bool) {
+	var reason string
+	l.startLiteral()
+
+	if l.ch == '0' {
+		l.readChar()
+		if isDigit(l.ch) {
+			reason = "leading zeros are not allowed"
+			l.readDigits()
+		}
+	} else {
+		l.readDigits()
+	}
 
-	for isDigit(l.ch) || (l.ch == '.' && !readDecimalPoint) {
-		if l.ch == '.' {
-			readDecimalPoint = true
+	if l.ch == '.' {
+		isFloat = true
+		l.readChar()
+		if !isDigit(l.ch) && reason == "" {
+			reason = "expected digit after decimal point"
 		}
+		l.readDigits()
+	}
+
+	if l.ch == 'e' || l.ch == 'E' {
+		isFloat = true
 		l.readChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		if !isDigit(l.ch) && reason == "" {
+			reason = "expected digit in exponent"
+		}
+		l.readDigits()
 	}
 
-	return l.input[start:l.position]
+	// A number must not run into letters or another decimal point, as in "1x" or "1.2.3".
+	if isLetter(l.ch) || l.ch == '.' {
+		if reason == "" {
+			reason = "unexpected character after number"
+		}
+		for isLetter(l.ch) || isDigit(l.ch) || l.ch == '.' {
+			l.readChar()
+		}
+	}
+
+	literal = l.endLiteral()
+	if reason != "" {
+		l.addError(start, fmt.Sprintf("invalid number %q: %s.", literal, reason))
+		return literal, isFloat, false
+	}
+
+	return literal, isFloat, true
+}
+
+// readDigits advances the position until it encounters a non-digit character.
+func (l *lexer) readDigits() {
+	for isDigit(l.ch) {
+		l.readChar()
+	}
 }
 
 // readKeyword returns a string of keywords.
 // It advances the position until it encounters a non-alphabetic character.
 func (l *lexer) readKeyword() string {
-	start := l.position
+	l.startLiteral()
 
 	for isLetter(l.ch) {
 		l.readChar()
 	}
 
-	return l.input[start:l.position]
+	return l.endLiteral()
 }
 
 // isLetter returns true if the character is either an alphabet or an underscore character, false otherwise.
@@ -155,6 +504,86 @@ func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+// addError records an error found at pos while reading the input.
+func (l *lexer) addError(pos Position, msg string) {
+	l.errors = append(l.errors, l.newSyntaxError(pos, msg))
+}
+
+// newSyntaxError returns a SyntaxError at pos, including a snippet of the input around it.
+func (l *lexer) newSyntaxError(pos Position, msg string) *SyntaxError {
+	return &SyntaxError{Position: pos, Msg: msg, Snippet: l.snippet(pos.Offset)}
+}
+
+// snippet returns the line containing offset followed by a line with a caret pointing at offset.
+// Only the most recently read bytes are kept, so the snippet is empty if offset is too far behind,
+// and only input that has already been buffered is shown after offset.
+func (l *lexer) snippet(offset int) string {
+	if offset < l.historyStart {
+		return ""
+	}
+
+	window := l.history
+	if need := offset + snippetContext + 1 - (l.historyStart + len(l.history)); need > 0 && !l.atEOF {
+		if n := l.r.Buffered(); need > n {
+			need = n
+		}
+		ahead, _ := l.r.Peek(need)
+		window = append(window[:len(window):len(window)], ahead...)
+	}
+
+	// Positions past the end of the input point just after its last byte.
+	rel := offset - l.historyStart
+	if rel > len(window) {
+		rel = len(window)
+	}
+
+	return snippet(window, rel, l.historyStart > 0)
+}
+
+// snippet returns the line of data containing offset followed by a line with a caret pointing at offset.
+// Long lines are clipped to snippetContext bytes on either side of offset.
+// truncated reports whether data is preceded by input that is not available.
+func snippet(data []byte, offset int, truncated bool) string {
+	start := bytes.LastIndexByte(data[:offset], '\n') + 1
+	end := len(data)
+	if i := bytes.IndexAny(data[offset:], "\r\n"); i >= 0 {
+		end = offset + i
+	}
+
+	clipped := start == 0 && truncated
+	if offset-start > snippetContext {
+		start = offset - snippetContext
+		for start < offset && !utf8.RuneStart(data[start]) {
+			start++
+		}
+		clipped = true
+	}
+	if end-offset > snippetContext {
+		end = offset + snippetContext
+		for end > offset && !utf8.RuneStart(data[end]) {
+			end--
+		}
+	}
+
+	var out strings.Builder
+	var caret strings.Builder
+	if clipped {
+		out.WriteString("...")
+		caret.WriteString("   ")
+	}
+	out.Write(data[start:end])
+	for _, r := range string(data[start:offset]) {
+		if r == '\t' {
+			caret.WriteByte('\t')
+		} else {
+			caret.WriteByte(' ')
+		}
+	}
+	caret.WriteByte('^')
+
+	return out.String() + "\n" + caret.String()
+}
+
 // newToken initializes a token and returns it.
 func newToken(tokenType tokenType, ch byte) token {
 	return token{Type: tokenType, Literal: string(ch)}
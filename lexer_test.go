@@ -75,3 +75,168 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestReadString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"plain"`, "plain"},
+		{`"quote \" backslash \\ slash \/"`, `quote " backslash \ slash /`},
+		{`"\b\f\n\r\t"`, "\b\f\n\r\t"},
+		{`"\u0041\u00e9\u20AC"`, "Aé€"},
+		{`"\ud83d\ude00"`, "😀"},
+		{`"日本語"`, "日本語"},
+	}
+
+	for i, tt := range tests {
+		l := newLexer(tt.input)
+		tok := l.nextToken()
+
+		if tok.Type != tokString {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q.", i, tokString, tok.Type)
+		}
+		if tok.Literal != tt.expected {
+			t.Errorf("tests[%d] - literal wrong. expected=%q, got=%q.", i, tt.expected, tok.Literal)
+		}
+	}
+}
+
+func TestReadStringErrors(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedError  string
+		expectedOffset int
+	}{
+		{`"abc`, "unterminated string.", 0},
+		{`"\`, "unterminated string.", 0},
+		{`"\x"`, `invalid escape sequence "\x" in string.`, 1},
+		{`"\u12"`, "invalid unicode escape sequence in string.", 1},
+		{`"ab\uZZZZ"`, "invalid unicode escape sequence in string.", 3},
+		{`"\ud83d"`, `unpaired surrogate \ud83d in string.`, 1},
+		{`"\ud83dx"`, `unpaired surrogate \ud83d in string.`, 1},
+		{`"\ud83d\u0041"`, `unpaired surrogate \ud83d in string.`, 1},
+		{`"\ude00"`, `unpaired surrogate \ude00 in string.`, 1},
+	}
+
+	for i, tt := range tests {
+		l := newLexer(tt.input)
+		tok := l.nextToken()
+
+		if tok.Type != tokIllegal {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q.", i, tokIllegal, tok.Type)
+		}
+		if len(l.errors) == 0 {
+			t.Fatalf("tests[%d] - error expected, got none.", i)
+		}
+		if l.errors[0].Msg != tt.expectedError {
+			t.Errorf("tests[%d] - unexpected error. expected=%q, got=%q.", i, tt.expectedError, l.errors[0].Msg)
+		}
+		if l.errors[0].Offset != tt.expectedOffset {
+			t.Errorf("tests[%d] - error offset wrong. expected=%d, got=%d.", i, tt.expectedOffset, l.errors[0].Offset)
+		}
+	}
+}
+
+func TestTokenPosition(t *testing.T) {
+	input := "{\n  \"名前\": \"é\",\n\t\"n\": -12\n}"
+
+	tests := []struct {
+		expectedType tokenType
+		expectedPos  Position
+	}{
+		{tokLBrace, Position{Offset: 0, Line: 1, Column: 1}},
+		{tokString, Position{Offset: 4, Line: 2, Column: 3}},
+		{tokColon, Position{Offset: 12, Line: 2, Column: 7}},
+		{tokString, Position{Offset: 14, Line: 2, Column: 9}},
+		{tokComma, Position{Offset: 18, Line: 2, Column: 12}},
+		{tokString, Position{Offset: 21, Line: 3, Column: 2}},
+		{tokColon, Position{Offset: 24, Line: 3, Column: 5}},
+		{tokMinus, Position{Offset: 26, Line: 3, Column: 7}},
+		{tokInt, Position{Offset: 27, Line: 3, Column: 8}},
+		{tokRBrace, Position{Offset: 30, Line: 4, Column: 1}},
+		{tokEOF, Position{Offset: 31, Line: 4, Column: 2}},
+	}
+
+	l := newLexer(input)
+
+	for i, tt := range tests {
+		tok := l.nextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q.", i, tt.expectedType, tok.Type)
+		}
+		if tok.Pos != tt.expectedPos {
+			t.Errorf("tests[%d] - position wrong. expected=%+v, got=%+v.", i, tt.expectedPos, tok.Pos)
+		}
+	}
+}
+
+func TestReadNumber(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    tokenType
+		expectedLiteral string
+	}{
+		{"0", tokInt, "0"},
+		{"42", tokInt, "42"},
+		{"0.5", tokFloat, "0.5"},
+		{"3.1415", tokFloat, "3.1415"},
+		{"1e10", tokFloat, "1e10"},
+		{"6.02E+23", tokFloat, "6.02E+23"},
+		{"1.5e-3", tokFloat, "1.5e-3"},
+		{"0e0", tokFloat, "0e0"},
+		{"10]", tokInt, "10"},
+		{"2.5,", tokFloat, "2.5"},
+	}
+
+	for i, tt := range tests {
+		l := newLexer(tt.input)
+		tok := l.nextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q.", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("tests[%d] - literal wrong. expected=%q, got=%q.", i, tt.expectedLiteral, tok.Literal)
+		}
+		if len(l.errors) != 0 {
+			t.Errorf("tests[%d] - unexpected error %q.", i, l.errors[0].Msg)
+		}
+	}
+}
+
+func TestReadNumberErrors(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedError  string
+		expectedOffset int
+	}{
+		{"01", `invalid number "01": leading zeros are not allowed.`, 0},
+		{"[007]", `invalid number "007": leading zeros are not allowed.`, 1},
+		{"1.", `invalid number "1.": expected digit after decimal point.`, 0},
+		{"1.e5", `invalid number "1.e5": expected digit after decimal point.`, 0},
+		{"1e", `invalid number "1e": expected digit in exponent.`, 0},
+		{"1e+", `invalid number "1e+": expected digit in exponent.`, 0},
+		{"1.2.3", `invalid number "1.2.3": unexpected character after number.`, 0},
+		{"12abc", `invalid number "12abc": unexpected character after number.`, 0},
+		{"- 1", "expected digit after '-'.", 0},
+		{"-.5", "expected digit after '-'.", 0},
+	}
+
+	for i, tt := range tests {
+		l := newLexer(tt.input)
+		for tok := l.nextToken(); tok.Type != tokEOF && len(l.errors) == 0; tok = l.nextToken() {
+		}
+
+		if len(l.errors) == 0 {
+			t.Fatalf("tests[%d] - error expected, got none.", i)
+		}
+		if l.errors[0].Msg != tt.expectedError {
+			t.Errorf("tests[%d] - unexpected error. expected=%q, got=%q.", i, tt.expectedError, l.errors[0].Msg)
+		}
+		if l.errors[0].Offset != tt.expectedOffset {
+			t.Errorf("tests[%d] - error offset wrong. expected=%d, got=%d.", i, tt.expectedOffset, l.errors[0].Offset)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package gj
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// SetOption configures Set, Append and Insert.
+type SetOption func(*setOptions)
+
+// setOptions holds the settings of a mutation.
+type setOptions struct {
+	createMissing bool
+}
+
+// CreateMissing creates the missing objects along the path instead of returning a key error.
+// With Append and Insert, a missing array at the end of the path is created as well.
+func CreateMissing() SetOption {
+	return func(o *setOptions) {
+		o.createMissing = true
+	}
+}
+
+// Set sets the value at path to value, which is converted as by FromValue, or copied if it is a *JSON.
+// If the last element of path is a key that does not exist, the member is added at the end of its object.
+// If it is an index, the element must exist. An empty path replaces the whole document.
+func (j *JSON) Set(path string, value interface{}, opts ...SetOption) error {
+	exp, err := toExpression(value)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		j.json.Value = exp
+		return nil
+	}
+
+	parent, last, err := j.parent(path, newSetOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	return setChild(parent, last, exp)
+}
+
+// Delete removes the value at path from its object or array.
+// If the key appears more than once in its object, every occurrence is removed.
+func (j *JSON) Delete(path string) error {
+	if path == "" {
+		return errors.New(`key error - ""`)
+	}
+
+	parent, last, err := j.parent(path, newSetOptions(nil))
+	if err != nil {
+		return err
+	}
+
+	return deleteChild(parent, last)
+}
+
+// Append adds value at the end of the array at path.
+// The value is converted as by Set.
+func (j *JSON) Append(path string, value interface{}, opts ...SetOption) error {
+	exp, err := toExpression(value)
+	if err != nil {
+		return err
+	}
+
+	arr, err := j.array(path, newSetOptions(opts))
+	if err != nil {
+		return err
+	}
+	arr.Values = append(arr.Values, exp)
+
+	return nil
+}
+
+// Insert inserts value into the array at path so that it becomes the element at index,
+// shifting the following elements. index may be equal to the length of the array.
+// The value is converted as by Set.
+func (j *JSON) Insert(path string, index int, value interface{}, opts ...SetOption) error {
+	exp, err := toExpression(value)
+	if err != nil {
+		return err
+	}
+
+	arr, err := j.array(path, newSetOptions(opts))
+	if err != nil {
+		return err
+	}
+	if index < 0 || index > len(arr.Values) {
+		return errors.New("index error - index out of bounds")
+	}
+
+	arr.Values = append(arr.Values, nil)
+	copy(arr.Values[index+1:], arr.Values[index:])
+	arr.Values[index] = exp
+
+	return nil
+}
+
+// setChild sets the member of the object or the element of the array parent selected by seg to exp.
+func setChild(parent expression, seg pathSegment, exp expression) error {
+	if seg.isIndex {
+		arr, ok := parent.(*arrayExpression)
+		if !ok {
+			return errors.New(`index error - cannot use "[]"`)
+		}
+		i, ok := resolveIndex(arr, seg.index)
+		if !ok {
+			return errors.New("index error - index out of bounds")
+		}
+		arr.Values[i] = exp
+		return nil
+	}
+
+	obj, ok := parent.(*objectExpression)
+	if !ok {
+		return fmt.Errorf(`key error - "%s"`, seg.key)
+	}
+	obj.set(newString(seg.key), exp)
+
+	return nil
+}
+
+// deleteChild removes the member of the object or the element of the array parent selected by seg.
+func deleteChild(parent expression, seg pathSegment) error {
+	if _, err := child(parent, seg); err != nil {
+		return err
+	}
+
+	if seg.isIndex {
+		arr := parent.(*arrayExpression)
+		i, _ := resolveIndex(arr, seg.index)
+		arr.Values = append(arr.Values[:i], arr.Values[i+1:]...)
+		return nil
+	}
+	parent.(*objectExpression).delete(seg.key)
+
+	return nil
+}
+
+// newSetOptions applies opts to the default settings.
+func newSetOptions(opts []SetOption) *setOptions {
+	o := &setOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// parent returns the expression containing the value at the non-empty path, and the last element of path.
+// Missing objects along the way are created if o.createMissing is set.
+func (j *JSON) parent(path string, o *setOptions) (expression, pathSegment, error) {
+	segs, err := parseSinglePath(path)
+	if err != nil {
+		return nil, pathSegment{}, err
+	}
+
+	exp := j.json.Value
+	for _, seg := range segs[:len(segs)-1] {
+		next, err := child(exp, seg)
+		if err != nil {
+			obj, ok := exp.(*objectExpression)
+			if !o.createMissing || seg.isIndex || !ok {
+				return nil, pathSegment{}, err
+			}
+			next = newObject()
+			obj.set(newString(seg.key), next)
+		}
+		exp = next
+	}
+
+	return exp, segs[len(segs)-1], nil
+}
+
+// array returns the array at path, creating it if it is missing and o.createMissing is set.
+func (j *JSON) array(path string, o *setOptions) (*arrayExpression, error) {
+	exp := j.json.Value
+	if path != "" {
+		parent, last, err := j.parent(path, o)
+		if err != nil {
+			return nil, err
+		}
+
+		exp, err = child(parent, last)
+		if err != nil {
+			obj, ok := parent.(*objectExpression)
+			if !o.createMissing || last.isIndex || !ok {
+				return nil, err
+			}
+			exp = newArray()
+			obj.set(newString(last.key), exp)
+		}
+	}
+
+	arr, ok := exp.(*arrayExpression)
+	if !ok {
+		return nil, fmt.Errorf(`type error - "%s" is not an array`, path)
+	}
+
+	return arr, nil
+}
+
+// toExpression converts value to an expression.
+// A *JSON is copied so that later changes to either document do not affect the other.
+func toExpression(value interface{}) (expression, error) {
+	if j, ok := value.(*JSON); ok {
+		return cloneExpression(j.json.Value), nil
+	}
+
+	e := &encodeState{visited: map[uintptr]bool{}}
+	return e.value(reflect.ValueOf(value), "")
+}
+
+// cloneExpression returns a deep copy of exp.
+func cloneExpression(exp expression) expression {
+	switch exp := exp.(type) {
+	case *prefixExpression:
+		c := *exp
+		c.Right = cloneExpression(exp.Right)
+		return &c
+	case *objectExpression:
+		c := newObject()
+		c.Token = exp.Token
+		for _, pair := range exp.Pairs {
+			key := *pair.Key
+			c.add(&key, cloneExpression(pair.Value))
+		}
+		return c
+	case *arrayExpression:
+		c := newArray()
+		c.Token = exp.Token
+		for _, value := range exp.Values {
+			c.Values = append(c.Values, cloneExpression(value))
+		}
+		return c
+	}
+
+	// Scalars are never modified in place.
+	return exp
+}
@@ -0,0 +1,188 @@
+package gj
+
+import (
+	"testing"
+)
+
+const mutateInput = `{"a": {"b": [1, 2, 3]}, "c": "x", "d": null}`
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		path     string
+		value    interface{}
+		opts     []SetOption
+		expected string
+	}{
+		{"c", "y", nil, `{"a": {"b": [1, 2, 3]}, "c": "y", "d": null}`},
+		{"e", []int{4}, nil, `{"a": {"b": [1, 2, 3]}, "c": "x", "d": null, "e": [4]}`},
+		{"a.b.[1]", map[string]bool{"t": true}, nil, `{"a": {"b": [1, {"t": true}, 3]}, "c": "x", "d": null}`},
+		{"a.b.[-1]", 4, nil, `{"a": {"b": [1, 2, 4]}, "c": "x", "d": null}`},
+		{"a.b", -1.5, nil, `{"a": {"b": -1.5}, "c": "x", "d": null}`},
+		{"x.y.z", 1, []SetOption{CreateMissing()}, `{"a": {"b": [1, 2, 3]}, "c": "x", "d": null, "x": {"y": {"z": 1}}}`},
+		{"a.x.y", nil, []SetOption{CreateMissing()}, `{"a": {"b": [1, 2, 3], "x": {"y": null}}, "c": "x", "d": null}`},
+		{"", "root", nil, `"root"`},
+	}
+
+	for i, tt := range tests {
+		json, err := ParseString(mutateInput)
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+
+		if err := json.Set(tt.path, tt.value, tt.opts...); err != nil {
+			t.Errorf("[test %d] unexpected error - %q", i, err)
+			continue
+		}
+		if json.String() != tt.expected {
+			t.Errorf("[test %d] document wrong - got=%s, want=%s.", i, json, tt.expected)
+		}
+		if _, err := ParseString(json.String()); err != nil {
+			t.Errorf("[test %d] unexpected error - %q", i, err)
+		}
+	}
+}
+
+func TestSetJSON(t *testing.T) {
+	json, _ := ParseString(mutateInput)
+	other, _ := ParseString(`{"k": [true]}`)
+
+	if err := json.Set("d", other); err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if err := other.Append("k", false); err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	expected := `{"a": {"b": [1, 2, 3]}, "c": "x", "d": {"k": [true]}}`
+	if json.String() != expected {
+		t.Errorf("document wrong - got=%s, want=%s.", json, expected)
+	}
+	got, err := json.Get("d.k.[0]")
+	if err != nil || got != true {
+		t.Errorf("Get wrong - got=%v, err=%v.", got, err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"c", `{"a": {"b": [1, 2, 3]}, "d": null}`},
+		{"a.b.[0]", `{"a": {"b": [2, 3]}, "c": "x", "d": null}`},
+		{"a.b.[2]", `{"a": {"b": [1, 2]}, "c": "x", "d": null}`},
+		{"a.b.[-3]", `{"a": {"b": [2, 3]}, "c": "x", "d": null}`},
+		{"a", `{"c": "x", "d": null}`},
+	}
+
+	for i, tt := range tests {
+		json, err := ParseString(mutateInput)
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+
+		if err := json.Delete(tt.path); err != nil {
+			t.Errorf("[test %d] unexpected error - %q", i, err)
+			continue
+		}
+		if json.String() != tt.expected {
+			t.Errorf("[test %d] document wrong - got=%s, want=%s.", i, json, tt.expected)
+		}
+		if _, err := json.Get(tt.path); err == nil && tt.path == "c" {
+			t.Errorf("[test %d] deleted key still found.", i)
+		}
+	}
+}
+
+func TestDeleteDuplicateKeys(t *testing.T) {
+	json, _ := ParseString(`{"a": 1, "b": 2, "a": 3}`, WithDuplicateKeys(DuplicateKeyKeepAll))
+	if err := json.Delete("a"); err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if json.String() != `{"b": 2}` {
+		t.Errorf("document wrong - got=%s.", json)
+	}
+	if err := json.Set("b", 3); err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if got, _ := json.Get("b"); got != int64(3) {
+		t.Errorf("Get wrong - got=%v.", got)
+	}
+}
+
+func TestAppendInsert(t *testing.T) {
+	json, err := ParseString(mutateInput)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	steps := []func() error{
+		func() error { return json.Append("a.b", 4) },
+		func() error { return json.Insert("a.b", 0, 0) },
+		func() error { return json.Insert("a.b", 5, "end") },
+		func() error { return json.Insert("a.b", 2, []string{}) },
+		func() error { return json.Append("n.list", true, CreateMissing()) },
+		func() error { return json.Insert("m", 0, "first", CreateMissing()) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("[step %d] unexpected error - %q", i, err)
+		}
+	}
+
+	expected := `{"a": {"b": [0, 1, [], 2, 3, 4, "end"]}, "c": "x", "d": null, "n": {"list": [true]}, "m": ["first"]}`
+	if json.String() != expected {
+		t.Errorf("document wrong - got=%s, want=%s.", json, expected)
+	}
+
+	root, _ := ParseString(`[]`)
+	if err := root.Append("", 1); err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if root.String() != `[1]` {
+		t.Errorf("document wrong - got=%s.", root)
+	}
+}
+
+func TestMutationErrors(t *testing.T) {
+	tests := []struct {
+		mutate   func(json *JSON) error
+		expected string
+	}{
+		{func(json *JSON) error { return json.Set("x.y", 1) }, `key error - "x"`},
+		{func(json *JSON) error { return json.Set("c.y", 1) }, `key error - "y"`},
+		{func(json *JSON) error { return json.Set("c.y.z", 1, CreateMissing()) }, `key error - "y"`},
+		{func(json *JSON) error { return json.Set("a.b.[3]", 1) }, "index error - index out of bounds"},
+		{func(json *JSON) error { return json.Set("a.[0]", 1) }, `index error - cannot use "[]"`},
+		{func(json *JSON) error { return json.Set("a.[x]", 1) }, `index error - "[x]"`},
+		{func(json *JSON) error { return json.Set("a", make(chan int)) }, "type error - cannot marshal Go value of type chan int"},
+		{func(json *JSON) error { return json.Delete("") }, `key error - ""`},
+		{func(json *JSON) error { return json.Delete("x") }, `key error - "x"`},
+		{func(json *JSON) error { return json.Delete("a.b.[3]") }, "index error - index out of bounds"},
+		{func(json *JSON) error { return json.Delete("a.b.[-4]") }, "index error - index out of bounds"},
+		{func(json *JSON) error { return json.Delete("a.*") }, `path error - "a.*" can select more than one value`},
+		{func(json *JSON) error { return json.Append("c", 1) }, `type error - "c" is not an array`},
+		{func(json *JSON) error { return json.Append("x", 1) }, `key error - "x"`},
+		{func(json *JSON) error { return json.Insert("a.b", 4, 1) }, "index error - index out of bounds"},
+		{func(json *JSON) error { return json.Insert("a.b", -1, 1) }, "index error - index out of bounds"},
+	}
+
+	for i, tt := range tests {
+		json, err := ParseString(mutateInput)
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+
+		err = tt.mutate(json)
+		if err == nil {
+			t.Errorf("[test %d] expected error - want=%q.", i, tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("[test %d] unexpected error - got=%q, want=%q.", i, err, tt.expected)
+		}
+		if json.String() != mutateInput {
+			t.Errorf("[test %d] document changed - got=%s.", i, json)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package gj
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberPattern matches the number grammar of RFC 8259.
+var numberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// Number is a JSON number kept as its literal, so that no precision is lost. Numbers are
+// returned as Number with NumberPreserve, and a Number is written back as the same number by FromValue.
+type Number string
+
+// String returns the literal of the number.
+func (n Number) String() string {
+	return string(n)
+}
+
+// Float64 returns the number as a float64.
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// Int64 returns the number as an int64. It fails if the number has a fraction or
+// exponent, or is beyond the range of int64.
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+// evalNumber returns the number exp converted according to mode.
+func evalNumber(exp expression, mode NumberMode) (interface{}, error) {
+	literal := numberLiteral(exp)
+
+	switch mode {
+	case NumberPreserve:
+		return Number(literal), nil
+	case NumberBigIntOrBigFloat:
+		if isIntegerLiteral(literal) {
+			n, ok := new(big.Int).SetString(literal, 10)
+			if !ok {
+				return nil, fmt.Errorf("value error - %s cannot be represented as *big.Int", literal)
+			}
+			return n, nil
+		}
+		return parseBigFloat(literal), nil
+	case NumberBigFloat:
+		return parseBigFloat(literal), nil
+	case NumberBigRat:
+		// SetString fails for exponents too large to expand, such as 1e9999999.
+		r, ok := new(big.Rat).SetString(literal)
+		if !ok {
+			return nil, fmt.Errorf("value error - %s cannot be represented as *big.Rat", literal)
+		}
+		return r, nil
+	}
+
+	if mode != NumberFloat64 {
+		switch exp := exp.(type) {
+		case *integerExpression:
+			return exp.Value, nil
+		case *prefixExpression:
+			if right, ok := exp.Right.(*integerExpression); ok {
+				return -right.Value, nil
+			}
+		}
+		if isIntegerLiteral(literal) {
+			if n, err := strconv.ParseInt(literal, 10, 64); err == nil {
+				return n, nil
+			}
+			if n, err := strconv.ParseUint(literal, 10, 64); err == nil {
+				return n, nil
+			}
+			if overflowsInt64(literal) {
+				return nil, fmt.Errorf("value error - %s overflows int64", literal)
+			}
+		}
+	}
+	f, _ := strconv.ParseFloat(literal, 64)
+	return f, nil
+}
+
+// overflowsInt64 reports whether the integer literal is negative and its magnitude fits in uint64
+// but not in int64, so that it can be evaluated as neither.
+func overflowsInt64(literal string) bool {
+	if !strings.HasPrefix(literal, "-") {
+		return false
+	}
+	_, err := strconv.ParseUint(literal[1:], 10, 64)
+	return err == nil
+}
+
+// isIntegerLiteral reports whether the number literal has neither a fraction nor an exponent.
+func isIntegerLiteral(literal string) bool {
+	return !strings.ContainsAny(literal, ".eE")
+}
+
+// floatPrecision returns a precision in bits that holds every digit of the number literal.
+func floatPrecision(literal string) uint {
+	// Each decimal digit needs less than 4 bits.
+	if prec := uint(len(literal)) * 4; prec > 64 {
+		return prec
+	}
+	return 64
+}
+
+// parseBigFloat returns the number literal as a *big.Float with enough precision for every digit.
+func parseBigFloat(literal string) *big.Float {
+	f, _, _ := big.ParseFloat(literal, 10, floatPrecision(literal), big.ToNearestEven)
+	return f
+}
@@ -0,0 +1,201 @@
+package gj
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const numberInput = `[1, -2, 1.5, 1e2, 12345678901234567890123, -0.1000000000000000000001]`
+
+func TestNumberModes(t *testing.T) {
+	tests := []struct {
+		mode     NumberMode
+		expected []string
+	}{
+		{NumberInt64OrFloat64, []string{"int64 1", "int64 -2", "float64 1.5", "float64 100", "float64 1.2345678901234568e+22", "float64 -0.1"}},
+		{NumberFloat64, []string{"float64 1", "float64 -2", "float64 1.5", "float64 100", "float64 1.2345678901234568e+22", "float64 -0.1"}},
+		{NumberPreserve, []string{"gj.Number 1", "gj.Number -2", "gj.Number 1.5", "gj.Number 1e2",
+			"gj.Number 12345678901234567890123", "gj.Number -0.1000000000000000000001"}},
+		{NumberBigIntOrBigFloat, []string{"*big.Int 1", "*big.Int -2", "*big.Float 1.5", "*big.Float 100",
+			"*big.Int 12345678901234567890123", "*big.Float -0.1000000000000000000001"}},
+		{NumberBigFloat, []string{"*big.Float 1", "*big.Float -2", "*big.Float 1.5", "*big.Float 100",
+			"*big.Float 1.2345678901234567890123e+22", "*big.Float -0.1000000000000000000001"}},
+		{NumberBigRat, []string{"*big.Rat 1/1", "*big.Rat -2/1", "*big.Rat 3/2", "*big.Rat 100/1",
+			"*big.Rat 12345678901234567890123/1", "*big.Rat -1000000000000000000001/10000000000000000000000"}},
+	}
+
+	for i, tt := range tests {
+		json, err := ParseString(numberInput, WithNumbers(tt.mode))
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+		values, err := json.GetArray("")
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+
+		got := []string{}
+		for _, v := range values {
+			if f, ok := v.(*big.Float); ok {
+				got = append(got, fmt.Sprintf("%T %s", v, f.Text('g', -1)))
+				continue
+			}
+			got = append(got, fmt.Sprintf("%T %v", v, v))
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("[test %d] values wrong - got=%q, want=%q.", i, got, tt.expected)
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	n := Number("-12")
+	if n.String() != "-12" {
+		t.Errorf("String wrong - got=%q.", n.String())
+	}
+	if i, err := n.Int64(); err != nil || i != -12 {
+		t.Errorf("Int64 wrong - got=%d, %v.", i, err)
+	}
+	if f, err := n.Float64(); err != nil || f != -12 {
+		t.Errorf("Float64 wrong - got=%g, %v.", f, err)
+	}
+	if _, err := Number("1.5").Int64(); err == nil {
+		t.Errorf("Int64 of 1.5 should fail.")
+	}
+	if _, err := Number("99999999999999999999").Int64(); err == nil {
+		t.Errorf("Int64 of 99999999999999999999 should fail.")
+	}
+}
+
+func TestNumberRoundTrip(t *testing.T) {
+	input := `{"id": 123456789012345678901234567890, "amount": -0.100000000000000000000000000001}`
+	json, err := ParseString(input, WithNumbers(NumberPreserve))
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if json.String() != input {
+		t.Errorf("String wrong - got=%s.", json.String())
+	}
+
+	value, _ := json.Get("")
+	data, err := Marshal(value)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if string(data) != `{"amount": -0.100000000000000000000000000001, "id": 123456789012345678901234567890}` {
+		t.Errorf("Marshal wrong - got=%s.", data)
+	}
+}
+
+func TestUint64Numbers(t *testing.T) {
+	json, err := ParseString(`{"id": 18446744073709551615, "min": -9223372036854775808, "max": 9223372036854775808, "big": 18446744073709551616}`)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected interface{}
+	}{
+		{"id", uint64(18446744073709551615)},
+		{"min", int64(math.MinInt64)},
+		{"max", uint64(9223372036854775808)},
+		{"big", 18446744073709551616.0},
+	}
+
+	for i, tt := range tests {
+		got, err := json.Get(tt.path)
+		if err != nil {
+			t.Errorf("[test %d] unexpected error - %q", i, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("[test %d] value wrong - got=%#v, want=%#v.", i, got, tt.expected)
+		}
+	}
+
+	id, err := json.GetUint64("id")
+	if err != nil || id != math.MaxUint64 {
+		t.Errorf("GetUint64 wrong - got=%d, %v.", id, err)
+	}
+
+	values, err := json.Query(`$[?@ == 18446744073709551615]`)
+	if err != nil || len(values) != 1 {
+		t.Errorf("Query wrong - got=%d values, %v.", len(values), err)
+	}
+}
+
+func TestUint64Overflow(t *testing.T) {
+	input := `{"a": [-10000000000000000000], "b": -100000000000000000000}`
+	json, err := ParseString(input)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	overflow := "value error - -10000000000000000000 overflows int64"
+	tests := []struct {
+		desc string
+		err  func() error
+	}{
+		{"Get", func() error { _, err := json.Get("a.[0]"); return err }},
+		{"Get of the parent", func() error { _, err := json.Get(""); return err }},
+		{"GetAll", func() error { _, err := json.GetAll("a.[*]"); return err }},
+		{"GetArray", func() error { _, err := json.GetArray("a"); return err }},
+		{"GetInt64", func() error { _, err := json.GetInt64("a.[0]"); return err }},
+		{"Unmarshal", func() error {
+			var v interface{}
+			return Unmarshal([]byte(input), &v)
+		}},
+		{"Decoder.Token", func() error {
+			d := NewDecoder(strings.NewReader(`-10000000000000000000`))
+			_, err := d.Token()
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		err := tt.err()
+		if err == nil || !strings.Contains(err.Error(), overflow) {
+			t.Errorf("%s - unexpected error - got=%v, want=%q.", tt.desc, err, overflow)
+		}
+	}
+
+	if got, err := json.Get("b"); err != nil || got != -1e20 {
+		t.Errorf("value of b wrong - got=%#v, %v.", got, err)
+	}
+	if got, err := json.GetNumber("a.[0]"); err != nil || got != "-10000000000000000000" {
+		t.Errorf("GetNumber wrong - got=%q, %v.", got, err)
+	}
+
+	json, err = ParseString(input, WithNumbers(NumberFloat64))
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if got, err := json.Get("a.[0]"); err != nil || got != -1e19 {
+		t.Errorf("value wrong - got=%#v, %v.", got, err)
+	}
+}
+
+func TestBigRatOutOfRange(t *testing.T) {
+	input := `{"a": 1e9999999}`
+	json, err := ParseString(input, WithNumbers(NumberBigRat))
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	expected := "value error - 1e9999999 cannot be represented as *big.Rat"
+	if got, err := json.Get("a"); err == nil || err.Error() != expected {
+		t.Errorf("Get - unexpected result - got=%#v, %v, want=%q.", got, err, expected)
+	}
+	if got, err := json.Get(""); err == nil || err.Error() != expected {
+		t.Errorf("Get of the parent - unexpected result - got=%#v, %v, want=%q.", got, err, expected)
+	}
+	var v interface{}
+	if err := Unmarshal([]byte(input), &v, WithNumbers(NumberBigRat)); err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("Unmarshal - unexpected result - got=%#v, %v, want=%q.", v, err, expected)
+	}
+}
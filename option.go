@@ -0,0 +1,140 @@
+package gj
+
+// Option configures how the input is parsed.
+// The defaults match the behavior of ParseString without options:
+//
+//   - no limit on nesting depth, input size or string length
+//   - DuplicateKeyLastWins for repeated object keys
+//   - NumberInt64OrFloat64 for numbers
+//   - ModeDefault for the syntax accepted
+//   - comments are not allowed
+type Option func(*options)
+
+type options struct {
+	maxDepth        int
+	maxInputSize    int
+	maxStringLength int
+	duplicateKeys   DuplicateKeyPolicy
+	numbers         NumberMode
+	mode            Mode
+	comments        bool
+}
+
+// newOptions returns the default options with opts applied.
+func newOptions(opts []Option) *options {
+	o := &options{
+		duplicateKeys: DuplicateKeyLastWins,
+		numbers:       NumberInt64OrFloat64,
+		mode:          ModeDefault,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithMaxDepth limits how deeply objects and arrays can be nested.
+// A value of 0 or less means no limit.
+func WithMaxDepth(depth int) Option {
+	return func(o *options) {
+		o.maxDepth = depth
+	}
+}
+
+// WithMaxInputSize limits the size of the input in bytes.
+// A value of 0 or less means no limit.
+func WithMaxInputSize(size int) Option {
+	return func(o *options) {
+		o.maxInputSize = size
+	}
+}
+
+// WithMaxStringLength limits the length in bytes of each decoded string, including object keys.
+// A value of 0 or less means no limit.
+func WithMaxStringLength(length int) Option {
+	return func(o *options) {
+		o.maxStringLength = length
+	}
+}
+
+// DuplicateKeyPolicy determines what happens when a key appears more than once in an object.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeyLastWins keeps the value of the last occurrence of a key at the position of the first one.
+	// This is the default.
+	DuplicateKeyLastWins DuplicateKeyPolicy = iota
+	// DuplicateKeyFirstWins keeps the value of the first occurrence of a key and ignores the rest.
+	DuplicateKeyFirstWins
+	// DuplicateKeyReject reports a *DuplicateKeyError wrapped in a *SyntaxError.
+	DuplicateKeyReject
+	// DuplicateKeyKeepAll keeps every occurrence of a key. Get returns the last one,
+	// and GetValues returns all of them.
+	DuplicateKeyKeepAll
+)
+
+// WithDuplicateKeys sets the policy applied to repeated keys in an object.
+func WithDuplicateKeys(policy DuplicateKeyPolicy) Option {
+	return func(o *options) {
+		o.duplicateKeys = policy
+	}
+}
+
+// NumberMode determines the Go type that numbers are returned as.
+type NumberMode int
+
+const (
+	// NumberInt64OrFloat64 returns integers as int64, or as uint64 if they are too large for int64,
+	// and other numbers as float64, including integers beyond the range of uint64. Negative integers
+	// whose magnitude fits in uint64 but not in int64 cannot be returned as either, and evaluating
+	// them reports an error. This is the default.
+	NumberInt64OrFloat64 NumberMode = iota
+	// NumberFloat64 returns every number as float64.
+	NumberFloat64
+	// NumberPreserve returns every number as a Number holding its literal.
+	NumberPreserve
+	// NumberBigIntOrBigFloat returns integers as *big.Int and other numbers as *big.Float,
+	// with enough precision for every digit of the literal.
+	NumberBigIntOrBigFloat
+	// NumberBigFloat returns every number as a *big.Float, with enough precision for every digit of the literal.
+	NumberBigFloat
+	// NumberBigRat returns every number as a *big.Rat, which holds its exact value.
+	NumberBigRat
+)
+
+// WithNumbers sets the Go type that numbers are returned as.
+func WithNumbers(mode NumberMode) Option {
+	return func(o *options) {
+		o.numbers = mode
+	}
+}
+
+// Mode determines how strictly the input has to follow RFC 8259.
+type Mode int
+
+const (
+	// ModeDefault accepts RFC 8259 JSON, but also allows unescaped control characters and
+	// invalid UTF-8 in strings and a trailing comma after the last member of an object,
+	// and ignores anything after the top-level value. This is the default.
+	ModeDefault Mode = iota
+	// ModeStrict accepts only RFC 8259 JSON: strings must be valid UTF-8 without unescaped control characters,
+	// trailing commas are not allowed, and nothing but whitespace may follow the top-level value.
+	ModeStrict
+	// ModeLenient accepts everything ModeDefault does, and also allows a trailing comma
+	// after the last element of an array.
+	ModeLenient
+)
+
+// WithMode sets how strictly the input has to follow RFC 8259.
+func WithMode(mode Mode) Option {
+	return func(o *options) {
+		o.mode = mode
+	}
+}
+
+// WithComments allows JavaScript-style line (//) and block (/* */) comments wherever whitespace is allowed.
+func WithComments() Option {
+	return func(o *options) {
+		o.comments = true
+	}
+}
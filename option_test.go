@@ -0,0 +1,89 @@
+package gj
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		desc     string
+		input    string
+		opts     []Option
+		path     string
+		expected interface{}
+	}{
+		{"nesting within max depth", `{"a": [[1]]}`, []Option{WithMaxDepth(3)}, "a.[0].[0]", int64(1)},
+		{"input within max size", `[1, 2]`, []Option{WithMaxInputSize(6)}, "[1]", int64(2)},
+		{"string within max length", `["abcd"]`, []Option{WithMaxStringLength(4)}, "[0]", "abcd"},
+		{"escaped string within max length", `["éé"]`, []Option{WithMaxStringLength(4)}, "[0]", "éé"},
+		{"numbers as float64", `{"a": 1, "b": -2}`, []Option{WithNumbers(NumberFloat64)}, "", map[string]interface{}{"a": 1.0, "b": -2.0}},
+		{"numbers as int64 or float64", `[1, 1.5]`, []Option{WithNumbers(NumberInt64OrFloat64)}, "", []interface{}{int64(1), 1.5}},
+		{"default mode allows control characters", "\"a\tb\"", nil, "", "a\tb"},
+		{"default mode allows trailing comma in objects", `{"a": 1,}`, nil, "a", int64(1)},
+		{"default mode ignores trailing content", `1 2`, nil, "", int64(1)},
+		{"strict mode", " [1, {\"a\": \"é\"}]\n", []Option{WithMode(ModeStrict)}, "[1].a", "é"},
+		{"lenient mode allows trailing comma in arrays", `[1, 2,]`, []Option{WithMode(ModeLenient)}, "", []interface{}{int64(1), int64(2)}},
+		{"lenient mode allows trailing comma in objects", `{"a": [1,],}`, []Option{WithMode(ModeLenient)}, "a", []interface{}{int64(1)}},
+		{"comments", "// header\n{\"a\": /* one */ 1, // trailing\n\"b\": 2}", []Option{WithComments()}, "b", int64(2)},
+	}
+
+	for _, tt := range tests {
+		json, err := ParseString(tt.input, tt.opts...)
+		if err != nil {
+			t.Fatalf("%s - unexpected error - %q", tt.desc, err.Error())
+		}
+
+		val, err := json.Get(tt.path)
+		if err != nil {
+			t.Fatalf("%s - unexpected error - %q", tt.desc, err.Error())
+		}
+		if !reflect.DeepEqual(val, tt.expected) {
+			t.Errorf("%s - Get result wrong. got=%#v, want=%#v", tt.desc, val, tt.expected)
+		}
+	}
+}
+
+func TestOptionErrors(t *testing.T) {
+	tests := []struct {
+		desc          string
+		input         string
+		opts          []Option
+		expectedError string
+	}{
+		{"max depth", `{"a": [[1]]}`, []Option{WithMaxDepth(2)}, "line 1, column 8: maximum nesting depth of 2 exceeded."},
+		{"max input size", `[1, 2]`, []Option{WithMaxInputSize(5)}, "line 1, column 6: input exceeds maximum size of 5 bytes."},
+		{"max string length", `["ab", "abcde"]`, []Option{WithMaxStringLength(4)}, "line 1, column 8: string exceeds maximum length of 4 bytes."},
+		{"max key length", `{"abcde": 1}`, []Option{WithMaxStringLength(4)}, "line 1, column 2: string exceeds maximum length of 4 bytes."},
+		{"strict control character", "[\"a\nb\"]", []Option{WithMode(ModeStrict)}, `line 1, column 4: invalid control character '\n' in string.`},
+		{"strict invalid UTF-8", "[\"a\xffb\"]", []Option{WithMode(ModeStrict)}, "line 1, column 2: invalid UTF-8 in string."},
+		{"strict trailing content", `[1] [2]`, []Option{WithMode(ModeStrict)}, "line 1, column 5: unexpected [ after top-level value."},
+		{"strict trailing comma", `{"a": 1,}`, []Option{WithMode(ModeStrict)}, "line 1, column 9: expected next token to be STRING, got } instead."},
+		{"default trailing comma in arrays", `[1,]`, nil, "line 1, column 4: no parse function for ] found."},
+		{"comments not allowed by default", `[1, /* two */ 2]`, nil, "line 1, column 5: no parse function for ILLEGAL found."},
+		{"unterminated comment", `[1] /* `, []Option{WithComments()}, "line 1, column 5: unterminated comment."},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseString(tt.input, tt.opts...)
+		if err == nil {
+			t.Fatalf("%s - error expected, got none.", tt.desc)
+		}
+		if err.Error() != tt.expectedError {
+			t.Errorf("%s - unexpected error - got=%q, want=%q.", tt.desc, err.Error(), tt.expectedError)
+		}
+	}
+}
+
+func TestMaxDepthDeeplyNested(t *testing.T) {
+	input := strings.Repeat("[", 10000) + strings.Repeat("]", 10000)
+
+	_, err := ParseString(input, WithMaxDepth(100))
+	if err == nil {
+		t.Fatalf("error expected, got none.")
+	}
+	if !strings.HasSuffix(err.Error(), "maximum nesting depth of 100 exceeded.") {
+		t.Errorf("unexpected error - %q", err.Error())
+	}
+}
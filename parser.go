@@ -9,7 +9,9 @@ type parseFn func() expression
 
 type parser struct {
 	l      *lexer
-	errors []string
+	opts   *options
+	errors []*SyntaxError
+	depth  int
 
 	curToken  token
 	peekToken token
@@ -18,10 +20,12 @@ type parser struct {
 }
 
 // newParser initializes a parser object and returns it.
+// The parser uses the options of l.
 func newParser(l *lexer) *parser {
 	p := &parser{
 		l:      l,
-		errors: []string{},
+		opts:   l.opts,
+		errors: []*SyntaxError{},
 	}
 
 	p.parseFns = make(map[tokenType]parseFn)
@@ -42,9 +46,13 @@ func newParser(l *lexer) *parser {
 }
 
 // nextToken advances the tokens.
+// An error found by the lexer while reading the new curToken is recorded once the token is reached,
+// so that errors are reported in the order the tokens are consumed.
 func (p *parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.nextToken()
+
+	p.reportTokenError(p.curToken)
 }
 
 // curTokenIs returns true if the type of curToken is t, false otherwise.
@@ -69,19 +77,55 @@ func (p *parser) expectPeek(t tokenType) bool {
 	}
 }
 
-// getErrors returns the slice of error messages.
-func (p *parser) getErrors() []string {
+// getErrors returns the slice of syntax errors.
+func (p *parser) getErrors() []*SyntaxError {
 	return p.errors
 }
 
+// addError records a syntax error at the position of tok.
+func (p *parser) addError(tok token, msg string) *SyntaxError {
+	err := p.l.newSyntaxError(tok.Pos, msg)
+	err.Got = string(tok.Type)
+	p.errors = append(p.errors, err)
+	return err
+}
+
+// reportTokenError records the error attached to tok by the lexer, unless it has just been recorded.
+// It returns true if tok has an error.
+func (p *parser) reportTokenError(tok token) bool {
+	if tok.Err == nil {
+		return false
+	}
+	if n := len(p.errors); n == 0 || p.errors[n-1] != tok.Err {
+		p.errors = append(p.errors, tok.Err)
+	}
+	return true
+}
+
 func (p *parser) noParseFnError(t tokenType) {
+	if p.curToken.Err != nil {
+		// The lexer has already reported why the token is illegal.
+		return
+	}
 	msg := fmt.Sprintf("no parse function for %s found.", t)
-	p.errors = append(p.errors, msg)
+	p.addError(p.curToken, msg)
 }
 
 func (p *parser) peekError(t tokenType) {
+	if p.reportTokenError(p.peekToken) {
+		return
+	}
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead.", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	err := p.addError(p.peekToken, msg)
+	err.Expected = string(t)
+}
+
+// duplicateKeyError records an error for the repeated key second, which was first defined at first.
+func (p *parser) duplicateKeyError(first, second *stringExpression) {
+	dup := &DuplicateKeyError{Key: second.Value, First: first.Token.Pos, Second: second.Token.Pos}
+	msg := fmt.Sprintf("duplicate key %q (first defined at line %d, column %d).", dup.Key, dup.First.Line, dup.First.Column)
+	err := p.addError(second.Token, msg)
+	err.Err = dup
 }
 
 // parse parses the input string and returns the result as an ast.jsonExpression.
@@ -89,10 +133,24 @@ func (p *parser) parse() *jsonExpression {
 	json := &jsonExpression{}
 
 	json.Value = p.parseExpression()
+	p.checkEnd()
 
 	return json
 }
 
+// checkEnd checks what follows the top-level value.
+// Errors at the end of the input, such as an unterminated comment, are reported in every mode,
+// but anything else is only an error in ModeStrict.
+func (p *parser) checkEnd() {
+	if p.peekTokenIs(tokEOF) {
+		p.reportTokenError(p.peekToken)
+	} else if p.opts.mode == ModeStrict && !p.reportTokenError(p.peekToken) {
+		msg := fmt.Sprintf("unexpected %s after top-level value.", p.peekToken.Type)
+		err := p.addError(p.peekToken, msg)
+		err.Expected = tokEOF
+	}
+}
+
 func (p *parser) parseExpression() expression {
 	prefix := p.parseFns[p.curToken.Type]
 	if prefix == nil {
@@ -116,11 +174,11 @@ func (p *parser) parseNull() expression {
 func (p *parser) parseInteger() expression {
 	i := &integerExpression{Token: p.curToken}
 
-	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	value, err := strconv.ParseInt(p.curToken.Literal, 10, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer.", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
-		return nil
+		// Integers beyond the range of int64 are kept as their literal, as floats are,
+		// and evaluated as uint64 if they fit.
+		return &floatExpression{Token: p.curToken, Value: p.curToken.Literal}
 	}
 
 	i.Value = value
@@ -150,8 +208,13 @@ func (p *parser) parseString() expression {
 }
 
 func (p *parser) parseObject() expression {
-	object := &objectExpression{Token: p.curToken}
-	object.Pairs = make(map[string]expression)
+	if !p.enter() {
+		return nil
+	}
+	defer p.leave()
+
+	object := &objectExpression{Token: p.curToken, index: map[string]int{}}
+	object.Pairs = []*objectPair{}
 
 	for !p.peekTokenIs(tokRBrace) {
 		if !p.expectPeek(tokString) {
@@ -167,11 +230,24 @@ func (p *parser) parseObject() expression {
 		p.nextToken()
 		value := p.parseExpression()
 
-		object.Pairs[key.Value] = value
+		prev, ok := object.pair(key.Value)
+		switch {
+		case !ok || p.opts.duplicateKeys == DuplicateKeyKeepAll:
+			object.add(key, value)
+		case p.opts.duplicateKeys == DuplicateKeyReject:
+			p.duplicateKeyError(prev.Key, key)
+			return nil
+		case p.opts.duplicateKeys == DuplicateKeyLastWins:
+			prev.Value = value
+		}
 
 		if !p.peekTokenIs(tokRBrace) && !p.expectPeek(tokComma) {
 			return nil
 		}
+		if p.curTokenIs(tokComma) && p.peekTokenIs(tokRBrace) && p.opts.mode == ModeStrict {
+			p.peekError(tokString)
+			return nil
+		}
 	}
 
 	if !p.expectPeek(tokRBrace) {
@@ -182,6 +258,11 @@ func (p *parser) parseObject() expression {
 }
 
 func (p *parser) parseArray() expression {
+	if !p.enter() {
+		return nil
+	}
+	defer p.leave()
+
 	array := &arrayExpression{Token: p.curToken}
 	array.Values = []expression{}
 
@@ -195,6 +276,9 @@ func (p *parser) parseArray() expression {
 
 	for p.peekTokenIs(tokComma) {
 		p.nextToken()
+		if p.peekTokenIs(tokRBracket) && p.opts.mode == ModeLenient {
+			break
+		}
 		p.nextToken()
 		array.Values = append(array.Values, p.parseExpression())
 	}
@@ -206,6 +290,24 @@ func (p *parser) parseArray() expression {
 	return array
 }
 
+// enter increases the nesting depth before parsing an object or an array.
+// It returns false and records an error if the maximum depth is exceeded.
+func (p *parser) enter() bool {
+	p.depth++
+	if p.opts.maxDepth > 0 && p.depth > p.opts.maxDepth {
+		msg := fmt.Sprintf("maximum nesting depth of %d exceeded.", p.opts.maxDepth)
+		p.addError(p.curToken, msg)
+		p.depth--
+		return false
+	}
+	return true
+}
+
+// leave decreases the nesting depth after parsing an object or an array.
+func (p *parser) leave() {
+	p.depth--
+}
+
 // registerParseFn registers functions to parse each token.
 func (p *parser) registerParseFn(tokenType tokenType, fn parseFn) {
 	p.parseFns[tokenType] = fn
@@ -129,6 +129,10 @@ func TestEmptyObjectExpression(t *testing.T) {
 	if len(object.Pairs) != 0 {
 		t.Errorf("objectExpression.Pairs has wrong length. got=%d.", len(object.Pairs))
 	}
+	// Lookups must not build the index, so that documents can be read concurrently.
+	if object.index == nil || newObject().index == nil {
+		t.Errorf("objectExpression.index not built on creation.")
+	}
 }
 
 func TestObjectExpression(t *testing.T) {
@@ -144,17 +148,22 @@ func TestObjectExpression(t *testing.T) {
 		t.Fatalf("json.Value not objectExpression. got=%T.", json.Value)
 	}
 
-	expected := map[string]int64{
-		"one":   1,
-		"two":   2,
-		"three": 3,
+	expected := []struct {
+		key   string
+		value int64
+	}{
+		{"one", 1},
+		{"two", 2},
+		{"three", 3},
 	}
 	if len(object.Pairs) != 3 {
-		t.Errorf("objectExpression.Pairs has wrong length. got=%d.", len(object.Pairs))
+		t.Fatalf("objectExpression.Pairs has wrong length. got=%d.", len(object.Pairs))
 	}
-	for key, value := range object.Pairs {
-		expectedValue := expected[key]
-		testNumber(t, value, expectedValue)
+	for i, pair := range object.Pairs {
+		if pair.Key.Value != expected[i].key {
+			t.Errorf("key %d not %q. got=%q.", i, expected[i].key, pair.Key.Value)
+		}
+		testNumber(t, pair.Value, expected[i].value)
 	}
 }
 
@@ -225,7 +234,10 @@ func TestIllegalExpression(t *testing.T) {
 		l := newLexer(tt.input)
 		p := newParser(l)
 		p.parse()
-		errors := p.getErrors()
+		var errors []string
+		for _, err := range p.getErrors() {
+			errors = append(errors, err.Msg)
+		}
 
 		if len(errors) == 0 {
 			t.Fatalf("expected to have errors, got none.")
@@ -280,8 +292,8 @@ func checkParserErrors(t *testing.T, p *parser) {
 	}
 
 	t.Errorf("parser has %d errors.", len(errors))
-	for _, msg := range errors {
-		t.Errorf("parser error: %q", msg)
+	for _, err := range errors {
+		t.Errorf("parser error: %q", err.Error())
 	}
 	t.FailNow()
 }
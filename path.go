@@ -0,0 +1,204 @@
+package gj
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A path selects a value in a document with a series of elements separated by dots.
+// Each element is one of:
+//
+//	key        a member of an object; \ escapes the next character, so that a key
+//	           can contain dots or backslashes, or start with [ or be *
+//	["key"]    a member of an object, with the key written as a JSON string
+//	[n]        the n-th element of an array, counting from the end if n is negative
+//	* or [*]   every member of an object or element of an array
+//
+// An element preceded by two dots instead of one, as in a..key or ..key, selects from the value
+// and from all of its descendants. The empty path selects the whole document.
+// Paths with * or .. can select several values, so they are only accepted by GetAll.
+
+// pathSegment is a single step of a path: an object key, an array index or a wildcard,
+// applied to the value or, if descendant is set, to the value and all of its descendants.
+type pathSegment struct {
+	key        string
+	index      int
+	isIndex    bool
+	wildcard   bool
+	descendant bool
+}
+
+// parsePath splits path into its elements and parses them.
+func parsePath(path string) ([]pathSegment, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	elems, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty element between two others stands for "..", which also starts a path at its first dot.
+	leading := strings.HasPrefix(path, "..")
+	if leading {
+		elems = elems[1:]
+	}
+
+	segs := make([]pathSegment, 0, len(elems))
+	for i := 0; i < len(elems); i++ {
+		descendant := false
+		if elems[i] == "" && i+1 < len(elems) && (i > 0 || leading) {
+			descendant = true
+			i++
+			if elems[i] == "" {
+				return nil, fmt.Errorf(`path error - expected an element after ".." in "%s"`, path)
+			}
+		}
+
+		seg, err := parseSegment(elems[i])
+		if err != nil {
+			return nil, err
+		}
+		seg.descendant = descendant
+		segs = append(segs, seg)
+	}
+
+	return segs, nil
+}
+
+// parseSinglePath parses path, which must select at most one value.
+func parseSinglePath(path string) ([]pathSegment, error) {
+	segs, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, seg := range segs {
+		if seg.wildcard || seg.descendant {
+			return nil, fmt.Errorf(`path error - "%s" can select more than one value`, path)
+		}
+	}
+
+	return segs, nil
+}
+
+// splitPath splits path at the dots that are neither escaped nor inside a quoted key.
+func splitPath(path string) ([]string, error) {
+	var elems []string
+
+	start := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			if i+1 == len(path) {
+				return nil, fmt.Errorf(`path error - trailing backslash in "%s"`, path)
+			}
+			i++
+		case '"':
+			if i != start+1 || path[start] != '[' {
+				continue
+			}
+			end := closingQuote(path, i+1)
+			if end < 0 {
+				return nil, fmt.Errorf(`path error - unterminated quoted key in "%s"`, path)
+			}
+			i = end
+		case '.':
+			elems = append(elems, path[start:i])
+			start = i + 1
+		}
+	}
+	elems = append(elems, path[start:])
+
+	return elems, nil
+}
+
+// closingQuote returns the position of the first unescaped double quote in path at or after i, or -1.
+func closingQuote(path string, i int) int {
+	for ; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseSegment parses a single element of a path.
+func parseSegment(elem string) (pathSegment, error) {
+	if strings.HasPrefix(elem, `["`) {
+		return parseQuotedKey(elem)
+	}
+	if elem == "*" || elem == "[*]" {
+		return pathSegment{wildcard: true}, nil
+	}
+	if !strings.HasPrefix(elem, "[") {
+		return pathSegment{key: unescapeKey(elem)}, nil
+	}
+
+	if elem == "[" || elem == "[]" || !strings.HasSuffix(elem, "]") {
+		return pathSegment{}, fmt.Errorf(`index error - "%s"`, elem)
+	}
+
+	index, err := strconv.ParseInt(elem[1:len(elem)-1], 10, 64)
+	if err != nil {
+		return pathSegment{}, fmt.Errorf(`index error - "%s"`, elem)
+	}
+
+	return pathSegment{index: int(index), isIndex: true}, nil
+}
+
+// parseQuotedKey parses an element of the form ["key"], where key is a JSON string.
+func parseQuotedKey(elem string) (pathSegment, error) {
+	if !strings.HasSuffix(elem, `"]`) || len(elem) < 4 {
+		return pathSegment{}, fmt.Errorf(`path error - expected "]" after quoted key in "%s"`, elem)
+	}
+
+	l := newLexer(elem[1:len(elem)-1], WithMode(ModeStrict))
+	tok := l.nextToken()
+	if tok.Type != tokString || tok.Err != nil || l.nextToken().Type != tokEOF {
+		return pathSegment{}, fmt.Errorf(`path error - invalid quoted key in "%s"`, elem)
+	}
+
+	return pathSegment{key: tok.Literal}, nil
+}
+
+// unescapeKey removes the backslashes escaping the characters of a plain key.
+func unescapeKey(elem string) string {
+	if !strings.Contains(elem, `\`) {
+		return elem
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(elem); i++ {
+		if elem[i] == '\\' && i+1 < len(elem) {
+			i++
+		}
+		out.WriteByte(elem[i])
+	}
+	return out.String()
+}
+
+// escapeKey escapes key so that it is read back as a single key.
+// The empty key is quoted, since an empty element can stand for "..".
+func escapeKey(key string) string {
+	if key == "" {
+		return `[""]`
+	}
+	if key == "*" {
+		return `\*`
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(key); i++ {
+		if key[i] == '.' || key[i] == '\\' || (i == 0 && key[i] == '[') {
+			out.WriteByte('\\')
+		}
+		out.WriteByte(key[i])
+	}
+	return out.String()
+}
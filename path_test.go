@@ -0,0 +1,115 @@
+package gj
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []pathSegment
+	}{
+		{"", nil},
+		{"foo", []pathSegment{{key: "foo"}}},
+		{"foo.[1].bar", []pathSegment{{key: "foo"}, {index: 1, isIndex: true}, {key: "bar"}}},
+		{`example\.com.port`, []pathSegment{{key: "example.com"}, {key: "port"}}},
+		{`["example.com"].port`, []pathSegment{{key: "example.com"}, {key: "port"}}},
+		{`a.["[draft]"]`, []pathSegment{{key: "a"}, {key: "[draft]"}}},
+		{`\[draft]`, []pathSegment{{key: "[draft]"}}},
+		{`["say \"hi\"."].["é\\"]`, []pathSegment{{key: `say "hi".`}, {key: "é\\"}}},
+		{`back\\slash`, []pathSegment{{key: `back\slash`}}},
+		{`a.[""].b`, []pathSegment{{key: "a"}, {key: ""}, {key: "b"}}},
+		{`[""]`, []pathSegment{{key: ""}}},
+		{`a.`, []pathSegment{{key: "a"}, {key: ""}}},
+		{`[-1]`, []pathSegment{{index: -1, isIndex: true}}},
+		{`a.*.[*]`, []pathSegment{{key: "a"}, {wildcard: true}, {wildcard: true}}},
+		{`\*`, []pathSegment{{key: "*"}}},
+		{`a..b`, []pathSegment{{key: "a"}, {key: "b", descendant: true}}},
+		{`..b.[0]`, []pathSegment{{key: "b", descendant: true}, {index: 0, isIndex: true}}},
+		{`..*`, []pathSegment{{wildcard: true, descendant: true}}},
+		{`a"b.c`, []pathSegment{{key: `a"b`}, {key: "c"}}},
+	}
+
+	for i, tt := range tests {
+		got, err := parsePath(tt.path)
+		if err != nil {
+			t.Errorf("[test %d] unexpected error - %q", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("[test %d] segments wrong - got=%+v, want=%+v.", i, got, tt.expected)
+		}
+	}
+}
+
+func TestParsePathErrors(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{`a\`, `path error - trailing backslash in "a\"`},
+		{`["abc`, `path error - unterminated quoted key in "["abc"`},
+		{`["a"]b.c`, `path error - expected "]" after quoted key in "["a"]b"`},
+		{`["a\x"]`, `path error - invalid quoted key in "["a\x"]"`},
+		{`["a"b"]`, `path error - invalid quoted key in "["a"b"]"`},
+		{`[1]x`, `index error - "[1]x"`},
+		{`[`, `index error - "["`},
+		{`a..`, `path error - expected an element after ".." in "a.."`},
+		{`a...b`, `path error - expected an element after ".." in "a...b"`},
+		{`..`, `path error - expected an element after ".." in ".."`},
+	}
+
+	for i, tt := range tests {
+		_, err := parsePath(tt.path)
+		if err == nil {
+			t.Errorf("[test %d] expected error - want=%q.", i, tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("[test %d] unexpected error - got=%q, want=%q.", i, err, tt.expected)
+		}
+	}
+}
+
+func TestGetEscapedKeys(t *testing.T) {
+	json, err := ParseString(`{"example.com": {"port": 80}, "[draft]": true, "a\\b": [1, {"x.y": "z"}]}`)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected interface{}
+	}{
+		{`example\.com.port`, int64(80)},
+		{`["example.com"].port`, int64(80)},
+		{`["[draft]"]`, true},
+		{`\[draft]`, true},
+		{`a\\b.[1].x\.y`, "z"},
+		{`["a\\b"].[1].["x.y"]`, "z"},
+	}
+
+	for i, tt := range tests {
+		got, err := json.Get(tt.path)
+		if err != nil {
+			t.Errorf("[test %d] unexpected error - %q", i, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("[test %d] value wrong - got=%v, want=%v.", i, got, tt.expected)
+		}
+	}
+
+	var paths []string
+	json.Root().Each(func(i int, key string, value Value) bool {
+		paths = append(paths, value.Path())
+		if _, err := json.Get(value.Path()); err != nil {
+			t.Errorf("[test %d] path of value not usable - %q", i, err)
+		}
+		return true
+	})
+	if expected := []string{`example\.com`, `\[draft]`, `a\\b`}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("paths wrong - got=%q, want=%q.", paths, expected)
+	}
+}
@@ -0,0 +1,160 @@
+package gj
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GetPointer returns the value in the JSON referred to by the JSON Pointer ptr, as defined by RFC 6901.
+// The empty pointer refers to the whole document. In each reference token, ~1 stands for / and ~0 for ~.
+// The token - refers to the position after the last element of an array, which never holds a value.
+func (j *JSON) GetPointer(ptr string) (interface{}, error) {
+	tokens, err := parsePointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	exp := j.json.Value
+	for _, tok := range tokens {
+		seg, err := pointerSegment(exp, tok)
+		if err != nil {
+			return nil, err
+		}
+		if exp, err = child(exp, seg); err != nil {
+			return nil, err
+		}
+	}
+
+	return j.eval(exp)
+}
+
+// SetPointer sets the value referred to by the JSON Pointer ptr, converting value as Set does.
+// If the last reference token is - and refers to an array, value is appended to it.
+func (j *JSON) SetPointer(ptr string, value interface{}, opts ...SetOption) error {
+	exp, err := toExpression(value)
+	if err != nil {
+		return err
+	}
+	tokens, err := parsePointer(ptr)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		j.json.Value = exp
+		return nil
+	}
+
+	parent, last, err := j.pointerParent(tokens, newSetOptions(opts))
+	if err != nil {
+		return err
+	}
+	if arr, ok := parent.(*arrayExpression); ok && last == "-" {
+		arr.Values = append(arr.Values, exp)
+		return nil
+	}
+
+	seg, err := pointerSegment(parent, last)
+	if err != nil {
+		return err
+	}
+
+	return setChild(parent, seg, exp)
+}
+
+// DeletePointer removes the value referred to by the JSON Pointer ptr from its object or array.
+func (j *JSON) DeletePointer(ptr string) error {
+	tokens, err := parsePointer(ptr)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return errors.New(`key error - ""`)
+	}
+
+	parent, last, err := j.pointerParent(tokens, newSetOptions(nil))
+	if err != nil {
+		return err
+	}
+	seg, err := pointerSegment(parent, last)
+	if err != nil {
+		return err
+	}
+
+	return deleteChild(parent, seg)
+}
+
+// pointerParent returns the expression containing the value referred to by the non-empty tokens,
+// and the last token. Missing objects along the way are created if o.createMissing is set.
+func (j *JSON) pointerParent(tokens []string, o *setOptions) (expression, string, error) {
+	exp := j.json.Value
+	for _, tok := range tokens[:len(tokens)-1] {
+		seg, err := pointerSegment(exp, tok)
+		if err != nil {
+			return nil, "", err
+		}
+
+		next, err := child(exp, seg)
+		if err != nil {
+			obj, ok := exp.(*objectExpression)
+			if !o.createMissing || !ok {
+				return nil, "", err
+			}
+			next = newObject()
+			obj.set(newString(seg.key), next)
+		}
+		exp = next
+	}
+
+	return exp, tokens[len(tokens)-1], nil
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// parsePointer splits the JSON Pointer ptr into its unescaped reference tokens.
+func parsePointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf(`pointer error - "%s" does not start with "/"`, ptr)
+	}
+
+	tokens := strings.Split(ptr[1:], "/")
+	for i, tok := range tokens {
+		for k := 0; k < len(tok); k++ {
+			if tok[k] != '~' {
+				continue
+			}
+			if k+1 == len(tok) || (tok[k+1] != '0' && tok[k+1] != '1') {
+				return nil, fmt.Errorf(`pointer error - invalid escape in "%s"`, tok)
+			}
+			k++
+		}
+		tokens[i] = pointerUnescaper.Replace(tok)
+	}
+
+	return tokens, nil
+}
+
+// pointerSegment interprets the reference token tok according to the type of exp:
+// as an index if exp is an array, and as a key otherwise.
+func pointerSegment(exp expression, tok string) (pathSegment, error) {
+	if _, ok := exp.(*arrayExpression); !ok {
+		return pathSegment{key: tok}, nil
+	}
+
+	if tok == "-" {
+		return pathSegment{}, errors.New("index error - index out of bounds")
+	}
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') || strings.TrimLeft(tok, "0123456789") != "" {
+		return pathSegment{}, fmt.Errorf(`index error - "%s"`, tok)
+	}
+	index, err := strconv.Atoi(tok)
+	if err != nil {
+		return pathSegment{}, errors.New("index error - index out of bounds")
+	}
+
+	return pathSegment{index: index, isIndex: true}, nil
+}
@@ -0,0 +1,147 @@
+package gj
+
+import (
+	"reflect"
+	"testing"
+)
+
+// The example document from RFC 6901, Section 5.
+const pointerInput = `{
+  "foo": ["bar", "baz"],
+  "": 0,
+  "a/b": 1,
+  "c%d": 2,
+  "e^f": 3,
+  "g|h": 4,
+  "i\\j": 5,
+  "k\"l": 6,
+  " ": 7,
+  "m~n": 8
+}`
+
+func TestGetPointer(t *testing.T) {
+	json, err := ParseString(pointerInput)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	tests := []struct {
+		ptr      string
+		expected interface{}
+	}{
+		{"/foo", []interface{}{"bar", "baz"}},
+		{"/foo/0", "bar"},
+		{"/", int64(0)},
+		{"/a~1b", int64(1)},
+		{"/c%d", int64(2)},
+		{"/e^f", int64(3)},
+		{"/g|h", int64(4)},
+		{"/i\\j", int64(5)},
+		{"/k\"l", int64(6)},
+		{"/ ", int64(7)},
+		{"/m~0n", int64(8)},
+	}
+
+	for i, tt := range tests {
+		got, err := json.GetPointer(tt.ptr)
+		if err != nil {
+			t.Errorf("[test %d] unexpected error - %q", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("[test %d] value wrong - got=%#v, want=%#v.", i, got, tt.expected)
+		}
+	}
+
+	whole, err := json.GetPointer("")
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if len(whole.(map[string]interface{})) != 10 {
+		t.Errorf("whole document wrong - got=%v.", whole)
+	}
+}
+
+func TestPointerErrors(t *testing.T) {
+	json, err := ParseString(`{"a": [1, {"0": "zero"}], "b": "s", "~": 1}`)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	if got, err := json.GetPointer("/a/1/0"); err != nil || got != "zero" {
+		t.Errorf("key that looks like an index wrong - got=%v, err=%v.", got, err)
+	}
+
+	tests := []struct {
+		ptr      string
+		expected string
+	}{
+		{"a", `pointer error - "a" does not start with "/"`},
+		{"/~", `pointer error - invalid escape in "~"`},
+		{"/a~2", `pointer error - invalid escape in "a~2"`},
+		{"/x", `key error - "x"`},
+		{"/b/c", `key error - "c"`},
+		{"/a/2", "index error - index out of bounds"},
+		{"/a/-", "index error - index out of bounds"},
+		{"/a/01", `index error - "01"`},
+		{"/a/+1", `index error - "+1"`},
+		{"/a/", `index error - ""`},
+		{"/a/99999999999999999999", "index error - index out of bounds"},
+	}
+
+	for i, tt := range tests {
+		_, err := json.GetPointer(tt.ptr)
+		if err == nil {
+			t.Errorf("[test %d] expected error - want=%q.", i, tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("[test %d] unexpected error - got=%q, want=%q.", i, err, tt.expected)
+		}
+	}
+}
+
+func TestSetDeletePointer(t *testing.T) {
+	json, err := ParseString(`{"a/b": {"list": [1, 2]}, "m~n": 0}`)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	steps := []func() error{
+		func() error { return json.SetPointer("/a~1b/list/0", "one") },
+		func() error { return json.SetPointer("/a~1b/list/-", 3) },
+		func() error { return json.SetPointer("/m~0n", true) },
+		func() error { return json.SetPointer("/new/x~1y", nil, CreateMissing()) },
+		func() error { return json.DeletePointer("/a~1b/list/1") },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("[step %d] unexpected error - %q", i, err)
+		}
+	}
+
+	expected := `{"a/b": {"list": ["one", 3]}, "m~n": true, "new": {"x/y": null}}`
+	if json.String() != expected {
+		t.Errorf("document wrong - got=%s, want=%s.", json, expected)
+	}
+
+	errTests := []struct {
+		err      error
+		expected string
+	}{
+		{json.SetPointer("/a~1b/list/2", 1), "index error - index out of bounds"},
+		{json.SetPointer("/x/y", 1), `key error - "x"`},
+		{json.DeletePointer("/a~1b/list/-"), "index error - index out of bounds"},
+		{json.DeletePointer("/missing"), `key error - "missing"`},
+		{json.DeletePointer(""), `key error - ""`},
+	}
+	for i, tt := range errTests {
+		if tt.err == nil || tt.err.Error() != tt.expected {
+			t.Errorf("[test %d] unexpected error - got=%v, want=%q.", i, tt.err, tt.expected)
+		}
+	}
+
+	if err := json.SetPointer("", []int{1}); err != nil || json.String() != `[1]` {
+		t.Errorf("replacing the document wrong - got=%s, err=%v.", json, err)
+	}
+}
@@ -0,0 +1,1560 @@
+package gj
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Program is a compiled jq-style expression. It can be run any number of times, on any document.
+type Program struct {
+	src  string
+	root jqNode
+}
+
+// Compile compiles the jq-style expression src into a Program. The language is a subset of jq:
+//
+//	.  .foo  ."foo"  .[e]  .[e:e]  .[]  ..  e?       paths, iteration and recursion
+//	e | e  e, e  e // e                              pipes, multiple outputs and alternatives
+//	+ - * / %  == != < <= > >=  and or               arithmetic, comparison and logic
+//	[e]  {a: e, "b": e, (e): e, c, $d}               array and object construction
+//	"text \(e)"  e as $x | e  $x                     string interpolation and variables
+//	if e then e elif e then e else e end             conditionals
+//	try e catch e  reduce e as $x (e; e)             error handling and reduction
+//
+// Functions include map, select, keys, length, add, has, to_entries, with_entries, sort_by, group_by,
+// unique, min, max, range, tostring, tonumber, split, join, test, sub, gsub, ascii_downcase and limit;
+// see builtin.go for the full list. Comments start with # and run to the end of the line.
+func Compile(src string) (*Program, error) {
+	p := &jqParser{src: src}
+	root, err := p.parsePipe(false)
+	if err != nil {
+		return nil, err
+	}
+	p.skip()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:p.pos+1])
+	}
+
+	return &Program{src: src, root: root}, nil
+}
+
+// Run runs the program with the JSON as its input and returns the outputs in order.
+// The outputs do not share any value with the input, so either can be modified afterwards.
+// Errors raised while running, by error or by invalid operations such as adding a number to a string,
+// are returned unless they are caught by try or ?.
+func (p *Program) Run(j *JSON) ([]*JSON, error) {
+	var outputs []*JSON
+	err := p.root.eval(j.json.Value, nil, func(v expression) error {
+		outputs = append(outputs, &JSON{json: &jsonExpression{Value: cloneExpression(v)}, opts: j.opts})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return outputs, nil
+}
+
+// String returns the source of the program.
+func (p *Program) String() string {
+	return p.src
+}
+
+// jqError is an error raised while running a program. Its value is what try ... catch receives.
+type jqError struct {
+	value expression
+}
+
+func (e *jqError) Error() string {
+	if s, ok := e.value.(*stringExpression); ok {
+		return "jq error - " + s.Value
+	}
+	return "jq error - " + jqText(e.value) + " (not a string)"
+}
+
+// jqErrorf returns a jqError whose value is the formatted message.
+func jqErrorf(format string, args ...interface{}) error {
+	return &jqError{value: newString(fmt.Sprintf(format, args...))}
+}
+
+// jqEmit receives an output of a node. An error stops the evaluation.
+type jqEmit func(expression) error
+
+// jqEnv binds a variable to a value. Bindings are chained to the enclosing ones.
+type jqEnv struct {
+	name   string
+	value  expression
+	parent *jqEnv
+}
+
+// lookup returns the value bound to name, which the parser has checked to be bound.
+func (e *jqEnv) lookup(name string) expression {
+	for ; e != nil; e = e.parent {
+		if e.name == name {
+			return e.value
+		}
+	}
+	return newNull()
+}
+
+// jqNode is a node of a compiled program. eval passes each output for input to emit.
+type jqNode interface {
+	eval(input expression, env *jqEnv, emit jqEmit) error
+}
+
+type (
+	jqIdentity struct{}
+	jqRecurse  struct{}
+	jqLiteral  struct{ value expression }
+	jqVar      struct{ name string }
+	jqIndex    struct{ target, index jqNode }
+	jqSlice    struct{ target, from, to jqNode } // from and to may be nil
+	jqIterate  struct{ target jqNode }
+	jqPipe     struct{ left, right jqNode }
+	jqComma    struct{ left, right jqNode }
+	jqAlt      struct{ left, right jqNode }
+	jqAnd      struct{ left, right jqNode }
+	jqOr       struct{ left, right jqNode }
+	jqNeg      struct{ operand jqNode }
+	jqArray    struct{ body jqNode } // body may be nil
+	jqString   struct{ parts []jqNode }
+	jqTry      struct{ body, handler jqNode } // handler may be nil
+	jqIf       struct{ cond, then, otherwise jqNode }
+	jqBinary   struct {
+		op          string
+		left, right jqNode
+	}
+	jqObject struct{ entries []jqEntry }
+	jqEntry  struct{ key, value jqNode }
+	jqBind   struct {
+		source jqNode
+		name   string
+		body   jqNode
+	}
+	jqReduce struct {
+		source       jqNode
+		name         string
+		init, update jqNode
+	}
+	jqCall struct {
+		fn   jqBuiltin
+		args []jqNode
+	}
+)
+
+func (n *jqIdentity) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return emit(input)
+}
+
+func (n *jqRecurse) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return jqRecurseValues(input, emit)
+}
+
+// jqRecurseValues passes v and each of its descendants to emit, parents first.
+func jqRecurseValues(v expression, emit jqEmit) error {
+	if err := emit(v); err != nil {
+		return err
+	}
+	for _, c := range jqChildren(v) {
+		if err := jqRecurseValues(c, emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *jqLiteral) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return emit(n.value)
+}
+
+func (n *jqVar) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return emit(env.lookup(n.name))
+}
+
+func (n *jqIndex) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return n.target.eval(input, env, func(t expression) error {
+		return n.index.eval(input, env, func(k expression) error {
+			v, err := jqIndexValue(t, k)
+			if err != nil {
+				return err
+			}
+			return emit(v)
+		})
+	})
+}
+
+// jqIndexValue returns the member of the object t named k, or the element of the array t at position k.
+func jqIndexValue(t, k expression) (expression, error) {
+	switch t := t.(type) {
+	case *nullExpression:
+		switch kindOf(k) {
+		case KindString, KindNumber, KindNull:
+			return newNull(), nil
+		}
+	case *objectExpression:
+		if k, ok := k.(*stringExpression); ok {
+			if v, ok := t.get(k.Value); ok {
+				return v, nil
+			}
+			return newNull(), nil
+		}
+	case *arrayExpression:
+		if kindOf(k) == KindNumber {
+			if i, ok := resolveIndex(t, int(math.Floor(jqFloat(k)))); ok {
+				return t.Values[i], nil
+			}
+			return newNull(), nil
+		}
+	}
+
+	if k, ok := k.(*stringExpression); ok {
+		return nil, jqErrorf("cannot index %s with %q", kindOf(t), k.Value)
+	}
+	return nil, jqErrorf("cannot index %s with %s", kindOf(t), kindOf(k))
+}
+
+func (n *jqSlice) eval(input expression, env *jqEnv, emit jqEmit) error {
+	bound := func(node jqNode, fn jqEmit) error {
+		if node == nil {
+			return fn(newNull())
+		}
+		return node.eval(input, env, fn)
+	}
+
+	return n.target.eval(input, env, func(t expression) error {
+		return bound(n.from, func(from expression) error {
+			return bound(n.to, func(to expression) error {
+				v, err := jqSliceValue(t, from, to)
+				if err != nil {
+					return err
+				}
+				return emit(v)
+			})
+		})
+	})
+}
+
+// jqSliceValue returns the elements of the array t, or the characters of the string t, from from to to.
+// Negative bounds count from the end, and null bounds stand for the start and the end.
+func jqSliceValue(t, from, to expression) (expression, error) {
+	if kindOf(t) == KindNull {
+		return newNull(), nil
+	}
+	for _, b := range []expression{from, to} {
+		if k := kindOf(b); k != KindNumber && k != KindNull {
+			return nil, jqErrorf("start and end indices of a slice must be numbers")
+		}
+	}
+
+	var n int
+	var runes []rune
+	switch t := t.(type) {
+	case *arrayExpression:
+		n = len(t.Values)
+	case *stringExpression:
+		runes = []rune(t.Value)
+		n = len(runes)
+	default:
+		return nil, jqErrorf("cannot index %s with object", kindOf(t))
+	}
+
+	clamp := func(b expression, def int, round func(float64) float64) int {
+		if kindOf(b) == KindNull {
+			return def
+		}
+		f := round(jqFloat(b))
+		if f < 0 {
+			f += float64(n)
+		}
+		return int(math.Max(0, math.Min(float64(n), f)))
+	}
+	start, end := clamp(from, 0, math.Floor), clamp(to, n, math.Ceil)
+	if end < start {
+		end = start
+	}
+
+	if arr, ok := t.(*arrayExpression); ok {
+		out := newArray()
+		out.Values = append(out.Values, arr.Values[start:end]...)
+		return out, nil
+	}
+	return newString(string(runes[start:end])), nil
+}
+
+func (n *jqIterate) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return n.target.eval(input, env, func(t expression) error {
+		if k := kindOf(t); k != KindArray && k != KindObject {
+			return jqErrorf("cannot iterate over %s", jqDescribe(t))
+		}
+		for _, v := range jqChildren(t) {
+			if err := emit(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// jqChildren returns the elements of the array v or the member values of the object v.
+func jqChildren(v expression) []expression {
+	switch v := v.(type) {
+	case *arrayExpression:
+		return v.Values
+	case *objectExpression:
+		values := make([]expression, 0, len(v.Pairs))
+		for _, pair := range v.Pairs {
+			values = append(values, pair.Value)
+		}
+		return values
+	}
+	return nil
+}
+
+func (n *jqPipe) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return n.left.eval(input, env, func(v expression) error {
+		return n.right.eval(v, env, emit)
+	})
+}
+
+func (n *jqComma) eval(input expression, env *jqEnv, emit jqEmit) error {
+	if err := n.left.eval(input, env, emit); err != nil {
+		return err
+	}
+	return n.right.eval(input, env, emit)
+}
+
+// jqCatch evaluates node and returns the error it raised itself, if any, as a *jqError.
+// Errors returned by emit, which were raised further down the pipe, are returned as downstream.
+func jqCatch(node jqNode, input expression, env *jqEnv, emit jqEmit) (raised *jqError, downstream error) {
+	err := node.eval(input, env, func(v expression) error {
+		if err := emit(v); err != nil {
+			downstream = err
+			return err
+		}
+		return nil
+	})
+	if err == nil || err == downstream {
+		return nil, downstream
+	}
+	if jerr, ok := err.(*jqError); ok {
+		return jerr, nil
+	}
+	return nil, err
+}
+
+func (n *jqTry) eval(input expression, env *jqEnv, emit jqEmit) error {
+	raised, err := jqCatch(n.body, input, env, emit)
+	if raised == nil || n.handler == nil {
+		return err
+	}
+	return n.handler.eval(raised.value, env, emit)
+}
+
+func (n *jqAlt) eval(input expression, env *jqEnv, emit jqEmit) error {
+	found := false
+	_, err := jqCatch(n.left, input, env, func(v expression) error {
+		if !jqTruthy(v) {
+			return nil
+		}
+		found = true
+		return emit(v)
+	})
+	if err != nil || found {
+		return err
+	}
+	return n.right.eval(input, env, emit)
+}
+
+func (n *jqAnd) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return n.left.eval(input, env, func(l expression) error {
+		if !jqTruthy(l) {
+			return emit(newBoolean(false))
+		}
+		return n.right.eval(input, env, func(r expression) error {
+			return emit(newBoolean(jqTruthy(r)))
+		})
+	})
+}
+
+func (n *jqOr) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return n.left.eval(input, env, func(l expression) error {
+		if jqTruthy(l) {
+			return emit(newBoolean(true))
+		}
+		return n.right.eval(input, env, func(r expression) error {
+			return emit(newBoolean(jqTruthy(r)))
+		})
+	})
+}
+
+func (n *jqNeg) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return n.operand.eval(input, env, func(v expression) error {
+		if kindOf(v) != KindNumber {
+			return jqErrorf("%s cannot be negated", jqDescribe(v))
+		}
+		return emit(jqNegate(v))
+	})
+}
+
+// jqNegate returns the number v with the opposite sign, keeping its literal.
+func jqNegate(v expression) expression {
+	if pe, ok := v.(*prefixExpression); ok {
+		return pe.Right
+	}
+	return newNegative(v)
+}
+
+func (n *jqArray) eval(input expression, env *jqEnv, emit jqEmit) error {
+	arr := newArray()
+	if n.body != nil {
+		err := n.body.eval(input, env, func(v expression) error {
+			arr.Values = append(arr.Values, v)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return emit(arr)
+}
+
+func (n *jqString) eval(input expression, env *jqEnv, emit jqEmit) error {
+	// As in jq, the last interpolation varies slowest.
+	var build func(i int, suffix string) error
+	build = func(i int, suffix string) error {
+		if i < 0 {
+			return emit(newString(suffix))
+		}
+		return n.parts[i].eval(input, env, func(v expression) error {
+			return build(i-1, jqToString(v)+suffix)
+		})
+	}
+	return build(len(n.parts)-1, "")
+}
+
+func (n *jqIf) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return n.cond.eval(input, env, func(c expression) error {
+		switch {
+		case jqTruthy(c):
+			return n.then.eval(input, env, emit)
+		case n.otherwise != nil:
+			return n.otherwise.eval(input, env, emit)
+		}
+		return emit(input)
+	})
+}
+
+func (n *jqBinary) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return n.right.eval(input, env, func(r expression) error {
+		return n.left.eval(input, env, func(l expression) error {
+			v, err := jqOperate(n.op, l, r)
+			if err != nil {
+				return err
+			}
+			return emit(v)
+		})
+	})
+}
+
+func (n *jqObject) eval(input expression, env *jqEnv, emit jqEmit) error {
+	var build func(i int, pairs []*objectPair) error
+	build = func(i int, pairs []*objectPair) error {
+		if i == len(n.entries) {
+			obj := newObject()
+			for _, pair := range pairs {
+				obj.set(pair.Key, pair.Value)
+			}
+			return emit(obj)
+		}
+
+		e := n.entries[i]
+		return e.key.eval(input, env, func(k expression) error {
+			key, ok := k.(*stringExpression)
+			if !ok {
+				return jqErrorf("object keys must be strings, not %s", jqDescribe(k))
+			}
+			return e.value.eval(input, env, func(v expression) error {
+				return build(i+1, append(pairs[:i:i], &objectPair{Key: key, Value: v}))
+			})
+		})
+	}
+	return build(0, nil)
+}
+
+func (n *jqBind) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return n.source.eval(input, env, func(v expression) error {
+		return n.body.eval(input, &jqEnv{name: n.name, value: v, parent: env}, emit)
+	})
+}
+
+func (n *jqReduce) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return n.init.eval(input, env, func(acc expression) error {
+		err := n.source.eval(input, env, func(x expression) error {
+			var last expression = newNull()
+			err := n.update.eval(acc, &jqEnv{name: n.name, value: x, parent: env}, func(v expression) error {
+				last = v
+				return nil
+			})
+			acc = last
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return emit(acc)
+	})
+}
+
+func (n *jqCall) eval(input expression, env *jqEnv, emit jqEmit) error {
+	return n.fn(input, n.args, env, emit)
+}
+
+// jqOperate applies the arithmetic or comparison operator op to l and r.
+func jqOperate(op string, l, r expression) (expression, error) {
+	switch op {
+	case "==":
+		return newBoolean(jqCompare(l, r) == 0), nil
+	case "!=":
+		return newBoolean(jqCompare(l, r) != 0), nil
+	case "<":
+		return newBoolean(jqCompare(l, r) < 0), nil
+	case "<=":
+		return newBoolean(jqCompare(l, r) <= 0), nil
+	case ">":
+		return newBoolean(jqCompare(l, r) > 0), nil
+	case ">=":
+		return newBoolean(jqCompare(l, r) >= 0), nil
+	}
+
+	lk, rk := kindOf(l), kindOf(r)
+	if lk == KindNumber && rk == KindNumber {
+		x, y := jqFloat(l), jqFloat(r)
+		switch op {
+		case "+":
+			return jqNumber(x + y), nil
+		case "-":
+			return jqNumber(x - y), nil
+		case "*":
+			return jqNumber(x * y), nil
+		case "/":
+			if y == 0 {
+				return nil, jqErrorf("%s and %s cannot be divided because the divisor is zero", jqDescribe(l), jqDescribe(r))
+			}
+			return jqNumber(x / y), nil
+		case "%":
+			if !jqFitsInt64(x) || !jqFitsInt64(y) {
+				return nil, jqErrorf("%s and %s cannot be divided because they are beyond the range of integers", jqDescribe(l), jqDescribe(r))
+			}
+			if int64(y) == 0 {
+				return nil, jqErrorf("%s and %s cannot be divided because the divisor is zero", jqDescribe(l), jqDescribe(r))
+			}
+			return newInteger(int64(x) % int64(y)), nil
+		}
+	}
+
+	switch {
+	case op == "+" && lk == KindNull:
+		return r, nil
+	case op == "+" && rk == KindNull:
+		return l, nil
+	case op == "+" && lk == KindString && rk == KindString:
+		return newString(l.(*stringExpression).Value + r.(*stringExpression).Value), nil
+	case op == "+" && lk == KindArray && rk == KindArray:
+		out := newArray()
+		out.Values = append(append(out.Values, l.(*arrayExpression).Values...), r.(*arrayExpression).Values...)
+		return out, nil
+	case op == "+" && lk == KindObject && rk == KindObject:
+		out := newObject()
+		for _, obj := range []*objectExpression{l.(*objectExpression), r.(*objectExpression)} {
+			for _, pair := range obj.Pairs {
+				out.set(pair.Key, pair.Value)
+			}
+		}
+		return out, nil
+	case op == "-" && lk == KindArray && rk == KindArray:
+		out := newArray()
+		for _, v := range l.(*arrayExpression).Values {
+			if !jqContainsValue(r.(*arrayExpression).Values, v) {
+				out.Values = append(out.Values, v)
+			}
+		}
+		return out, nil
+	case op == "*" && lk == KindObject && rk == KindObject:
+		return jqMerge(l.(*objectExpression), r.(*objectExpression)), nil
+	case op == "*" && (lk == KindString && rk == KindNumber || lk == KindNumber && rk == KindString):
+		s, n := l, r
+		if lk == KindNumber {
+			s, n = r, l
+		}
+		str, count := s.(*stringExpression).Value, math.Ceil(jqFloat(n))
+		if !(count > 0) {
+			return newNull(), nil
+		}
+		if len(str) == 0 {
+			return newString(""), nil
+		}
+		// Bound the count before converting it, so that neither it nor the length of the result overflows.
+		if count > float64(jqMaxRepeatLength/len(str)) {
+			return nil, jqErrorf("repeat string result too long")
+		}
+		return newString(strings.Repeat(str, int(count))), nil
+	case op == "/" && lk == KindString && rk == KindString:
+		return jqSplit(l.(*stringExpression).Value, r.(*stringExpression).Value), nil
+	}
+
+	verb := map[string]string{"+": "added", "-": "subtracted", "*": "multiplied", "/": "divided", "%": "divided"}[op]
+	return nil, jqErrorf("%s and %s cannot be %s", jqDescribe(l), jqDescribe(r), verb)
+}
+
+// jqMaxRepeatLength is the maximum length in bytes of a string repeated by multiplication.
+const jqMaxRepeatLength = math.MaxInt32
+
+// jqFitsInt64 reports whether the integral part of f is in the range of int64.
+func jqFitsInt64(f float64) bool {
+	return f >= math.MinInt64 && f < math.MaxInt64
+}
+
+// jqMerge merges the objects l and r recursively, with the values of r taking precedence.
+func jqMerge(l, r *objectExpression) *objectExpression {
+	out := newObject()
+	for _, pair := range l.Pairs {
+		out.set(pair.Key, pair.Value)
+	}
+	for _, pair := range r.Pairs {
+		if old, ok := out.get(pair.Key.Value); ok {
+			lo, lok := old.(*objectExpression)
+			ro, rok := pair.Value.(*objectExpression)
+			if lok && rok {
+				out.set(pair.Key, jqMerge(lo, ro))
+				continue
+			}
+		}
+		out.set(pair.Key, pair.Value)
+	}
+	return out
+}
+
+// jqSplit splits s around each occurrence of sep.
+func jqSplit(s, sep string) expression {
+	out := newArray()
+	if s == "" {
+		return out
+	}
+	for _, part := range strings.Split(s, sep) {
+		out.Values = append(out.Values, newString(part))
+	}
+	return out
+}
+
+// jqContainsValue reports whether values has an element equal to v.
+func jqContainsValue(values []expression, v expression) bool {
+	for _, x := range values {
+		if jqCompare(x, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// jqTruthy reports whether v counts as true: anything but false and null.
+func jqTruthy(v expression) bool {
+	switch v := v.(type) {
+	case *nullExpression:
+		return false
+	case *booleanExpression:
+		return v.Value
+	}
+	return true
+}
+
+// jqFloat returns the value of the number v.
+func jqFloat(v expression) float64 {
+	f, err := strconv.ParseFloat(numberLiteral(v), 64)
+	if err != nil {
+		// Out of range: ParseFloat returns ±Inf, which is clamped like other results.
+		return math.Max(-math.MaxFloat64, math.Min(math.MaxFloat64, f))
+	}
+	return f
+}
+
+// jqNumber returns a number expression for the result f of an operation.
+// Integral results are written without a fraction, NaN becomes null, and infinities are clamped.
+func jqNumber(f float64) expression {
+	switch {
+	case math.IsNaN(f):
+		return newNull()
+	case math.IsInf(f, 0):
+		f = math.Copysign(math.MaxFloat64, f)
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return newInteger(int64(f))
+	}
+	return newFloat(f, 64)
+}
+
+// jqOrder gives the position of the type of v in the order in which values are sorted.
+func jqOrder(v expression) int {
+	switch v := v.(type) {
+	case *nullExpression:
+		return 0
+	case *booleanExpression:
+		if v.Value {
+			return 2
+		}
+		return 1
+	case *stringExpression:
+		return 4
+	case *arrayExpression:
+		return 5
+	case *objectExpression:
+		return 6
+	}
+	return 3
+}
+
+// jqCompare compares a and b in the order of jq: null, false, true, numbers, strings, arrays, objects.
+// Arrays are compared element by element, and objects first by their sorted keys, then by their values.
+func jqCompare(a, b expression) int {
+	oa, ob := jqOrder(a), jqOrder(b)
+	if oa != ob {
+		return oa - ob
+	}
+
+	switch a := a.(type) {
+	case *stringExpression:
+		return strings.Compare(a.Value, b.(*stringExpression).Value)
+	case *arrayExpression:
+		y := b.(*arrayExpression).Values
+		for i, v := range a.Values {
+			if i == len(y) {
+				return 1
+			}
+			if c := jqCompare(v, y[i]); c != 0 {
+				return c
+			}
+		}
+		return len(a.Values) - len(y)
+	case *objectExpression:
+		x, y := members(a), members(b.(*objectExpression))
+		kx, ky := jqSortedKeys(x), jqSortedKeys(y)
+		for i := 0; i < len(kx) && i < len(ky); i++ {
+			if c := strings.Compare(kx[i], ky[i]); c != 0 {
+				return c
+			}
+		}
+		if len(kx) != len(ky) {
+			return len(kx) - len(ky)
+		}
+		for _, k := range kx {
+			if c := jqCompare(x[k], y[k]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+
+	if oa == 3 {
+		return compareNumbers(a, b)
+	}
+	return 0
+}
+
+// jqSortedKeys returns the keys of m in sorted order.
+func jqSortedKeys(m map[string]expression) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compactFormat writes values without whitespace, as tojson does.
+var compactFormat = &formatOptions{comma: ",", colon: ":"}
+
+// jqText returns v as compact JSON text.
+func jqText(v expression) string {
+	var out bytes.Buffer
+	writeExpression(&out, v, compactFormat)
+	return out.String()
+}
+
+// jqToString returns the string v itself, or any other value as JSON text.
+func jqToString(v expression) string {
+	if s, ok := v.(*stringExpression); ok {
+		return s.Value
+	}
+	return jqText(v)
+}
+
+// jqDescribe describes v for an error message by its type and a possibly truncated text.
+func jqDescribe(v expression) string {
+	text := jqText(v)
+	if len(text) > 30 {
+		cut := 27
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + "..."
+	}
+	return fmt.Sprintf("%s (%s)", kindOf(v), text)
+}
+
+// jqKeywords cannot be used as function names.
+var jqKeywords = map[string]bool{
+	"if": true, "then": true, "elif": true, "else": true, "end": true, "as": true, "def": true,
+	"reduce": true, "foreach": true, "try": true, "catch": true, "label": true, "import": true,
+	"include": true, "and": true, "or": true, "__loc__": true,
+}
+
+// jqParser parses the source of a program.
+type jqParser struct {
+	src  string
+	pos  int
+	vars []string // variables in scope, innermost last
+}
+
+// errorf returns an error at the current position.
+func (p *jqParser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.pos, format, args...)
+}
+
+// errorAt returns an error at pos.
+func (p *jqParser) errorAt(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("jq error - %s at position %d in %q", fmt.Sprintf(format, args...), pos, p.src)
+}
+
+// skip skips whitespace and comments.
+func (p *jqParser) skip() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		case '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the byte at offset n from the current position, or 0 past the end.
+func (p *jqParser) peek(n int) byte {
+	if p.pos+n < len(p.src) {
+		return p.src[p.pos+n]
+	}
+	return 0
+}
+
+// consume skips whitespace and advances past s if the input continues with it.
+func (p *jqParser) consume(s string) bool {
+	p.skip()
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// expect consumes s or returns an error.
+func (p *jqParser) expect(s string) error {
+	if !p.consume(s) {
+		return p.errorf("expected %q", s)
+	}
+	return nil
+}
+
+// keyword consumes the keyword kw if it comes next as a whole word.
+func (p *jqParser) keyword(kw string) bool {
+	p.skip()
+	if !strings.HasPrefix(p.src[p.pos:], kw) || isIdentChar(p.peek(len(kw))) {
+		return false
+	}
+	p.pos += len(kw)
+	return true
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+// ident reads an identifier, returning "" if none comes next.
+func (p *jqParser) ident() string {
+	start := p.pos
+	if !isIdentStart(p.peek(0)) {
+		return ""
+	}
+	for isIdentChar(p.peek(0)) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// parsePipe parses expressions separated by |. Inside object values, noComma stops at commas,
+// which separate the entries.
+func (p *jqParser) parsePipe(noComma bool) (jqNode, error) {
+	var left jqNode
+	var err error
+	if noComma {
+		left, err = p.parseAlt()
+	} else {
+		left, err = p.parseComma()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.consume("|") {
+		right, err := p.parsePipe(noComma)
+		if err != nil {
+			return nil, err
+		}
+		return &jqPipe{left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *jqParser) parseComma() (jqNode, error) {
+	left, err := p.parseAlt()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume(",") {
+		right, err := p.parseAlt()
+		if err != nil {
+			return nil, err
+		}
+		left = &jqComma{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *jqParser) parseAlt() (jqNode, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.consume("//") {
+		right, err := p.parseAlt()
+		if err != nil {
+			return nil, err
+		}
+		return &jqAlt{left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *jqParser) parseOr() (jqNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &jqOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *jqParser) parseAnd() (jqNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &jqAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *jqParser) parseComparison() (jqNode, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			return &jqBinary{op: op, left: left, right: right}, nil
+		}
+	}
+	if p.assignmentAhead() {
+		return nil, p.errorf("assignment is not supported")
+	}
+	return left, nil
+}
+
+// assignmentAhead reports whether an assignment operator such as =, |= or += follows.
+func (p *jqParser) assignmentAhead() bool {
+	switch c := p.peek(0); {
+	case c == '=':
+		return true
+	case c == '/' && p.peek(1) == '/':
+		return p.peek(2) == '='
+	case c != 0 && strings.IndexByte("|+-*/%", c) >= 0:
+		return p.peek(1) == '='
+	}
+	return false
+}
+
+func (p *jqParser) parseAdditive() (jqNode, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skip()
+		op := p.peek(0)
+		if (op != '+' && op != '-') || p.peek(1) == '=' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &jqBinary{op: string(op), left: left, right: right}
+	}
+}
+
+func (p *jqParser) parseMultiplicative() (jqNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skip()
+		op := p.peek(0)
+		if (op != '*' && op != '/' && op != '%') || p.peek(1) == '=' || (op == '/' && p.peek(1) == '/') {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &jqBinary{op: string(op), left: left, right: right}
+	}
+}
+
+func (p *jqParser) parseUnary() (jqNode, error) {
+	if !p.consume("-") {
+		return p.parsePostfix()
+	}
+
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if lit, ok := operand.(*jqLiteral); ok && kindOf(lit.value) == KindNumber {
+		return &jqLiteral{value: jqNegate(lit.value)}, nil
+	}
+	return &jqNeg{operand: operand}, nil
+}
+
+// parsePostfix parses a term with its paths, optionally followed by as $x | e.
+func (p *jqParser) parsePostfix() (jqNode, error) {
+	term, err := p.parsePostfixTerm()
+	if err != nil {
+		return nil, err
+	}
+	if p.keyword("as") {
+		return p.parseBind(term)
+	}
+	return term, nil
+}
+
+// parseFieldName parses the name after a dot: an identifier or a string.
+func (p *jqParser) parseFieldName() (jqNode, error) {
+	if p.peek(0) == '"' {
+		return p.parseString()
+	}
+	return &jqLiteral{value: newString(p.ident())}, nil
+}
+
+// parseBracket parses [], [e], [e:e], [e:] or [:e] applied to target.
+func (p *jqParser) parseBracket(target jqNode) (jqNode, error) {
+	p.pos++ // [
+	if p.consume("]") {
+		return &jqIterate{target: target}, nil
+	}
+
+	var from jqNode
+	if !p.consume(":") {
+		var err error
+		if from, err = p.parsePipe(false); err != nil {
+			return nil, err
+		}
+		if p.consume("]") {
+			return &jqIndex{target: target, index: from}, nil
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+	}
+
+	var to jqNode
+	if !p.consume("]") {
+		var err error
+		if to, err = p.parsePipe(false); err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+	if from == nil && to == nil {
+		return nil, p.errorf("slice needs a start or an end")
+	}
+
+	return &jqSlice{target: target, from: from, to: to}, nil
+}
+
+// parseBind parses $name | body after term as.
+func (p *jqParser) parseBind(source jqNode) (jqNode, error) {
+	name, err := p.parseVarName()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("|"); err != nil {
+		return nil, err
+	}
+
+	p.vars = append(p.vars, name)
+	body, err := p.parsePipe(false)
+	p.vars = p.vars[:len(p.vars)-1]
+	if err != nil {
+		return nil, err
+	}
+
+	return &jqBind{source: source, name: name, body: body}, nil
+}
+
+// parseVarName parses $name.
+func (p *jqParser) parseVarName() (string, error) {
+	if !p.consume("$") {
+		return "", p.errorf(`expected "$"`)
+	}
+	name := p.ident()
+	if name == "" {
+		return "", p.errorf("expected a variable name")
+	}
+	return name, nil
+}
+
+// parseTerm parses a path, a literal, a construction, a variable, a function call or a keyword construct.
+func (p *jqParser) parseTerm() (jqNode, error) {
+	p.skip()
+	start := p.pos
+
+	switch c := p.peek(0); {
+	case c == '.':
+		p.pos++
+		switch next := p.peek(0); {
+		case next == '.':
+			p.pos++
+			return &jqRecurse{}, nil
+		case isIdentStart(next) || next == '"':
+			key, err := p.parseFieldName()
+			return &jqIndex{target: &jqIdentity{}, index: key}, err
+		}
+		return &jqIdentity{}, nil
+	case c >= '0' && c <= '9':
+		return p.parseNumber()
+	case c == '"':
+		return p.parseString()
+	case c == '(':
+		p.pos++
+		body, err := p.parsePipe(false)
+		if err != nil {
+			return nil, err
+		}
+		return body, p.expect(")")
+	case c == '[':
+		p.pos++
+		if p.consume("]") {
+			return &jqArray{}, nil
+		}
+		body, err := p.parsePipe(false)
+		if err != nil {
+			return nil, err
+		}
+		return &jqArray{body: body}, p.expect("]")
+	case c == '{':
+		return p.parseObject()
+	case c == '$':
+		name, err := p.parseVarName()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range p.vars {
+			if v == name {
+				return &jqVar{name: name}, nil
+			}
+		}
+		return nil, p.errorAt(start, "$%s is not defined", name)
+	case isIdentStart(c):
+		return p.parseIdent()
+	case c == 0:
+		return nil, p.errorf("unexpected end of input")
+	}
+
+	return nil, p.errorf("unexpected %q", p.src[p.pos:p.pos+1])
+}
+
+// parseIdent parses a literal, a keyword construct or a function call.
+func (p *jqParser) parseIdent() (jqNode, error) {
+	start := p.pos
+	name := p.ident()
+
+	switch name {
+	case "true", "false":
+		return &jqLiteral{value: newBoolean(name == "true")}, nil
+	case "null":
+		return &jqLiteral{value: newNull()}, nil
+	case "if":
+		return p.parseIf()
+	case "try":
+		body, err := p.parsePostfixTerm()
+		if err != nil {
+			return nil, err
+		}
+		n := &jqTry{body: body}
+		if p.keyword("catch") {
+			n.handler, err = p.parsePostfixTerm()
+		}
+		return n, err
+	case "reduce":
+		return p.parseReduce()
+	}
+	if jqKeywords[name] {
+		return nil, p.errorAt(start, "unexpected keyword %q", name)
+	}
+
+	var args []jqNode
+	if p.consume("(") {
+		for {
+			arg, err := p.parsePipe(false)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.consume(")") {
+				break
+			}
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	fn, ok := jqBuiltins[name+"/"+strconv.Itoa(len(args))]
+	if !ok {
+		return nil, p.errorAt(start, "%s/%d is not defined", name, len(args))
+	}
+	return &jqCall{fn: fn, args: args}, nil
+}
+
+// parsePostfixTerm parses a term followed by any number of paths and ?.
+func (p *jqParser) parsePostfixTerm() (jqNode, error) {
+	term, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skip()
+		switch c, next := p.peek(0), p.peek(1); {
+		case c == '[':
+			term, err = p.parseBracket(term)
+		case c == '.' && next == '[':
+			p.pos++
+			term, err = p.parseBracket(term)
+		case c == '.' && (isIdentStart(next) || next == '"'):
+			p.pos++
+			var key jqNode
+			key, err = p.parseFieldName()
+			term = &jqIndex{target: term, index: key}
+		case c == '?':
+			p.pos++
+			term = &jqTry{body: term}
+		default:
+			return term, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseIf parses the rest of if c then e (elif c then e)* (else e)? end.
+func (p *jqParser) parseIf() (jqNode, error) {
+	cond, err := p.parsePipe(false)
+	if err != nil {
+		return nil, err
+	}
+	if !p.keyword("then") {
+		return nil, p.errorf(`expected "then"`)
+	}
+	then, err := p.parsePipe(false)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &jqIf{cond: cond, then: then}
+	switch {
+	case p.keyword("elif"):
+		n.otherwise, err = p.parseIf()
+		return n, err
+	case p.keyword("else"):
+		if n.otherwise, err = p.parsePipe(false); err != nil {
+			return nil, err
+		}
+	}
+	if !p.keyword("end") {
+		return nil, p.errorf(`expected "end"`)
+	}
+	return n, nil
+}
+
+// parseReduce parses the rest of reduce source as $x (init; update).
+func (p *jqParser) parseReduce() (jqNode, error) {
+	source, err := p.parsePostfixTerm()
+	if err != nil {
+		return nil, err
+	}
+	if !p.keyword("as") {
+		return nil, p.errorf(`expected "as"`)
+	}
+	name, err := p.parseVarName()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	init, err := p.parsePipe(false)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(";"); err != nil {
+		return nil, err
+	}
+
+	p.vars = append(p.vars, name)
+	update, err := p.parsePipe(false)
+	p.vars = p.vars[:len(p.vars)-1]
+	if err != nil {
+		return nil, err
+	}
+
+	return &jqReduce{source: source, name: name, init: init, update: update}, p.expect(")")
+}
+
+// parseObject parses an object construction.
+func (p *jqParser) parseObject() (jqNode, error) {
+	p.pos++ // {
+	n := &jqObject{}
+	if p.consume("}") {
+		return n, nil
+	}
+
+	for {
+		p.skip()
+		var e jqEntry
+		var err error
+		computed := false
+		switch c := p.peek(0); {
+		case c == '$':
+			// {$x} stands for {x: $x}.
+			start := p.pos
+			var name string
+			if name, err = p.parseVarName(); err != nil {
+				return nil, err
+			}
+			p.pos = start
+			e.key = &jqLiteral{value: newString(name)}
+			e.value, err = p.parseTerm()
+		case c == '"':
+			e.key, err = p.parseString()
+		case c == '(':
+			p.pos++
+			computed = true
+			if e.key, err = p.parsePipe(false); err == nil {
+				err = p.expect(")")
+			}
+		case isIdentStart(c):
+			e.key = &jqLiteral{value: newString(p.ident())}
+		default:
+			return nil, p.errorf("expected an object key")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case e.value != nil:
+		case p.consume(":"):
+			if e.value, err = p.parsePipe(true); err != nil {
+				return nil, err
+			}
+		case computed:
+			return nil, p.errorf(`expected ":"`)
+		default:
+			// {a} and {"a"} stand for {a: .a} and {"a": ."a"}.
+			e.value = &jqIndex{target: &jqIdentity{}, index: e.key}
+		}
+		n.entries = append(n.entries, e)
+
+		if p.consume("}") {
+			return n, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseNumber parses a number literal.
+func (p *jqParser) parseNumber() (jqNode, error) {
+	start := p.pos
+	digits := func() {
+		for c := p.peek(0); c >= '0' && c <= '9'; c = p.peek(0) {
+			p.pos++
+		}
+	}
+
+	digits()
+	isFloat := false
+	if p.peek(0) == '.' && p.peek(1) >= '0' && p.peek(1) <= '9' {
+		isFloat = true
+		p.pos++
+		digits()
+	}
+	if c := p.peek(0); c == 'e' || c == 'E' {
+		isFloat = true
+		p.pos++
+		if c := p.peek(0); c == '+' || c == '-' {
+			p.pos++
+		}
+		if c := p.peek(0); c < '0' || c > '9' {
+			return nil, p.errorAt(start, "invalid number")
+		}
+		digits()
+	}
+
+	literal := p.src[start:p.pos]
+	if !isFloat {
+		// Integers keep their literal, so that larger ones such as 64-bit IDs compare exactly. Leading
+		// zeros are dropped first, as JSON does not allow them in the output.
+		if literal = strings.TrimLeft(literal, "0"); literal == "" {
+			literal = "0"
+		}
+		return &jqLiteral{value: newNumber(literal)}, nil
+	}
+	f, _ := strconv.ParseFloat(literal, 64)
+	return &jqLiteral{value: jqNumber(f)}, nil
+}
+
+// parseString parses a string literal, which may contain interpolations \(e).
+func (p *jqParser) parseString() (jqNode, error) {
+	start := p.pos
+	p.pos++ // "
+
+	var parts []jqNode
+	var lit strings.Builder
+	for {
+		if p.pos >= len(p.src) {
+			return nil, p.errorAt(start, "unterminated string")
+		}
+
+		c := p.src[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			if len(parts) == 0 {
+				return &jqLiteral{value: newString(lit.String())}, nil
+			}
+			if lit.Len() > 0 {
+				parts = append(parts, &jqLiteral{value: newString(lit.String())})
+			}
+			return &jqString{parts: parts}, nil
+		case '\\':
+		default:
+			lit.WriteByte(c)
+			continue
+		}
+
+		switch e := p.peek(0); e {
+		case '(':
+			p.pos++
+			if lit.Len() > 0 {
+				parts = append(parts, &jqLiteral{value: newString(lit.String())})
+				lit.Reset()
+			}
+			part, err := p.parsePipe(false)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+			continue
+		case 'u':
+			r, err := p.parseUnicodeEscape()
+			if err != nil {
+				return nil, err
+			}
+			lit.WriteRune(r)
+			continue
+		case 'b':
+			lit.WriteByte('\b')
+		case 'f':
+			lit.WriteByte('\f')
+		case 'n':
+			lit.WriteByte('\n')
+		case 'r':
+			lit.WriteByte('\r')
+		case 't':
+			lit.WriteByte('\t')
+		case '"', '\\', '/':
+			lit.WriteByte(e)
+		default:
+			return nil, p.errorAt(p.pos-1, "invalid escape sequence in string")
+		}
+		p.pos++
+	}
+}
+
+// parseUnicodeEscape parses a \uXXXX escape in a string of a program, reporting errors at its backslash.
+func (p *jqParser) parseUnicodeEscape() (rune, error) {
+	start := p.pos - 1
+	p.pos++ // u
+	r, reason := decodeUnicodeEscape(func() byte { return p.peek(0) }, func() { p.pos++ })
+	if reason != "" {
+		return 0, p.errorAt(start, "%s in string", reason)
+	}
+	return r, nil
+}
@@ -0,0 +1,224 @@
+package gj
+
+import (
+	"reflect"
+	"testing"
+)
+
+const programInput = `{
+	"a": [1, 2, 3],
+	"b": {"c": "x", "d": null},
+	"users": [
+		{"name": "Al", "age": 30, "tags": ["admin"]},
+		{"name": "Bo", "age": 20, "tags": []}
+	]
+}`
+
+// runProgram compiles src, runs it on input and returns the outputs in compact form.
+func runProgram(t *testing.T, src, input string) ([]string, error) {
+	prog, err := Compile(src)
+	if err != nil {
+		return nil, err
+	}
+	json, err := ParseString(input)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	outputs, err := prog.Run(json)
+	if err != nil {
+		return nil, err
+	}
+	got := []string{}
+	for _, out := range outputs {
+		got = append(got, out.Compact())
+	}
+	return got, nil
+}
+
+func TestProgram(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected []string
+	}{
+		{`.`, []string{`{"a":[1,2,3],"b":{"c":"x","d":null},"users":[{"name":"Al","age":30,"tags":["admin"]},{"name":"Bo","age":20,"tags":[]}]}`}},
+		{`.b.c`, []string{`"x"`}},
+		{`.b."c"`, []string{`"x"`}},
+		{`.b["c"]`, []string{`"x"`}},
+		{`.missing.deeper`, []string{`null`}},
+		{`.a[0], .a[-1], .a[5]`, []string{`1`, `3`, `null`}},
+		{`.a[1:]`, []string{`[2,3]`}},
+		{`.a[:-1]`, []string{`[1,2]`}},
+		{`.b.c[0:1]`, []string{`"x"`}},
+		{`.a[]`, []string{`1`, `2`, `3`}},
+		{`.b[]`, []string{`"x"`, `null`}},
+		{`.users[].name`, []string{`"Al"`, `"Bo"`}},
+		{`.users[0].tags.[0]`, []string{`"admin"`}},
+		{`[..] | length`, []string{`18`}},
+		{`.a | .[0] + .[2]`, []string{`4`}},
+		{`.a[] | select(. > 1) | . * 10`, []string{`20`, `30`}},
+		{`[.a[] | . * 2]`, []string{`[2,4,6]`}},
+		{`[]`, []string{`[]`}},
+		{`{}`, []string{`{}`}},
+		{`{name: .users[0].name, n: (.users | length)}`, []string{`{"name":"Al","n":2}`}},
+		{`{a: 1, "b c": 2, ("d" + "e"): 3}`, []string{`{"a":1,"b c":2,"de":3}`}},
+		{`.b | {c, "d"}`, []string{`{"c":"x","d":null}`}},
+		{`.users[0] | {name, age: (.age + 1)}`, []string{`{"name":"Al","age":31}`}},
+		{`{a: (1, 2), b: (3, 4)}`, []string{`{"a":1,"b":3}`, `{"a":1,"b":4}`, `{"a":2,"b":3}`, `{"a":2,"b":4}`}},
+		{`{(.users[].name): 1}`, []string{`{"Al":1}`, `{"Bo":1}`}},
+		{`{a: .a | length}`, []string{`{"a":3}`}},
+		{`"n=\(.a[0]) b=\(.b) \(.b.c)"`, []string{`"n=1 b={\"c\":\"x\",\"d\":null} x"`}},
+		{`"\(1, 2)-\("a", "b")"`, []string{`"1-a"`, `"2-a"`, `"1-b"`, `"2-b"`}},
+		{`"\u00e9\n\""`, []string{`"é\n\""`}},
+		{`1 + 2 * 3 - 4 / 2`, []string{`5`}},
+		{`(1 + 2) * 3, 7 % 3, -7 % 3, 1.5 + 1.5, 1 / 3`, []string{`9`, `1`, `-1`, `3`, `0.3333333333333333`}},
+		{`"ab" * 2.5, "ab" * 0, "" * 1e300`, []string{`"ababab"`, `null`, `""`}},
+		{`-.a[0], -(1, 2), - 1`, []string{`-1`, `-1`, `-2`, `-1`}},
+		{`(1, 2) + (10, 20)`, []string{`11`, `12`, `21`, `22`}},
+		{`"ab" + "cd", [1] + [2], {a: 1} + {b: 2}, null + 1, 1 + null`, []string{`"abcd"`, `[1,2]`, `{"a":1,"b":2}`, `1`, `1`}},
+		{`[1, 2, 3, 2] - [2], "ab" * 3, {a: {b: 1, c: 2}} * {a: {c: 3}}, "a,b" / ","`, []string{`[1,3]`, `"ababab"`, `{"a":{"b":1,"c":3}}`, `["a","b"]`}},
+		{`1 == 1.0, 1 != 2, "a" < "b", [] > {}, null < false, 2 >= 2, {a: 1} == {a: 1}`, []string{`true`, `true`, `true`, `false`, `true`, `true`, `true`}},
+		{`true and null, false or 1, (true, false) and true`, []string{`false`, `true`, `true`, `false`}},
+		{`.missing // "default", .a // 1, (false, null) // 3, empty // 4, (1, null, 2) // 5`, []string{`"default"`, `[1,2,3]`, `3`, `4`, `1`, `2`}},
+		{`if .missing then "t" end | type, if .a[0] == 2 then "two" elif .a[0] == 1 then "one" else "other" end`, []string{`"object"`, `"one"`}},
+		{`.a[] | if . > 1 then "big" else "small" end`, []string{`"small"`, `"big"`, `"big"`}},
+		{`.a as $x | $x[0] + $x[1]`, []string{`3`}},
+		{`.users[] | .name as $n | .tags[] | "\($n):\(.)"`, []string{`"Al:admin"`}},
+		{`.users[].age as $a | $a * 2`, []string{`60`, `40`}},
+		{`1 as $x | 2 as $y | [$x, $y, {$x}]`, []string{`[1,2,{"x":1}]`}},
+		{`reduce .a[] as $x (0; . + $x)`, []string{`6`}},
+		{`reduce .users[] as $u ({}; . + {($u.name): $u.age})`, []string{`{"Al":30,"Bo":20}`}},
+		{`try error("boom") catch ., try error({a: 1}) catch .a`, []string{`"boom"`, `1`}},
+		{`try (1, error("x"), 3)`, []string{`1`}},
+		{`.b.c[]?, .a.b?, [.[]?]`, []string{`[[1,2,3],{"c":"x","d":null},[{"name":"Al","age":30,"tags":["admin"]},{"name":"Bo","age":20,"tags":[]}]]`}},
+		{`[.a[] | tostring] | join("-")`, []string{`"1-2-3"`}},
+		{`(.a | length), (.users | map(.age) | add)`, []string{`3`, `50`}},
+		{"# a comment\n.a[0] # another\n", []string{`1`}},
+		{`[limit(2; .a[])], first(.a[]), ([.a[] | select(. == 2)] | length)`, []string{`[1,2]`, `1`, `1`}},
+	}
+
+	for i, tt := range tests {
+		got, err := runProgram(t, tt.src, programInput)
+		if err != nil {
+			t.Errorf("[test %d] unexpected error for %q - %q", i, tt.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("[test %d] outputs of %q wrong - got=%q, want=%q.", i, tt.src, got, tt.expected)
+		}
+	}
+}
+
+func TestProgramReuse(t *testing.T) {
+	prog, err := Compile(`.a[] | . * 2`)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if prog.String() != `.a[] | . * 2` {
+		t.Errorf("String wrong - got=%q.", prog.String())
+	}
+
+	for i, input := range []string{`{"a": [1]}`, `{"a": [2, 3]}`} {
+		json, _ := ParseString(input)
+		outputs, err := prog.Run(json)
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+		if len(outputs) != i+1 {
+			t.Errorf("[test %d] number of outputs wrong - got=%d, want=%d.", i, len(outputs), i+1)
+		}
+	}
+
+	json, _ := ParseString(`{"a": {"b": [1]}}`)
+	prog, _ = Compile(`.a`)
+	outputs, _ := prog.Run(json)
+	if err := outputs[0].Append("b", 2); err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if json.String() != `{"a": {"b": [1]}}` {
+		t.Errorf("input changed by modifying an output - got=%s.", json.String())
+	}
+}
+
+func TestProgramLargeIntegers(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected []string
+	}{
+		{`.id == 12345678901234567891`, []string{`true`}},
+		{`.id == 12345678901234567890, .id > 12345678901234567890`, []string{`false`, `true`}},
+		{`12345678901234567891, -12345678901234567891 < -12345678901234567890`, []string{`12345678901234567891`, `true`}},
+		{`.id < 1e20, ([.id, 1] | sort | .[0])`, []string{`true`, `1`}},
+		{`007, -007, {a: 00}, (007 | tojson), 0012345678901234567891 == .id`, []string{`7`, `-7`, `{"a":0}`, `"7"`, `true`}},
+	}
+
+	for i, tt := range tests {
+		got, err := runProgram(t, tt.src, `{"id": 12345678901234567891}`)
+		if err != nil {
+			t.Errorf("[test %d] unexpected error for %q - %q", i, tt.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("[test %d] outputs of %q wrong - got=%q, want=%q.", i, tt.src, got, tt.expected)
+		}
+		for _, out := range got {
+			if _, err := ParseString(out); err != nil {
+				t.Errorf("[test %d] output %q of %q does not reparse - %q", i, out, tt.src, err)
+			}
+		}
+	}
+}
+
+func TestProgramErrors(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{`.a[`, `jq error - unexpected end of input at position 3 in ".a["`},
+		{`.a)`, `jq error - unexpected ")" at position 2 in ".a)"`},
+		{`foo`, `jq error - foo/0 is not defined at position 0 in "foo"`},
+		{`map(.; .)`, `jq error - map/2 is not defined at position 0 in "map(.; .)"`},
+		{`$x`, `jq error - $x is not defined at position 0 in "$x"`},
+		{`reduce .[] as $x ($x; .)`, `jq error - $x is not defined at position 18 in "reduce .[] as $x ($x; .)"`},
+		{`if . then 1`, `jq error - expected "end" at position 11 in "if . then 1"`},
+		{`.a = 1`, `jq error - assignment is not supported at position 3 in ".a = 1"`},
+		{`reduce .[] as $u ({}; .[$u.name] = $u.age)`, `jq error - assignment is not supported at position 33 in "reduce .[] as $u ({}; .[$u.name] = $u.age)"`},
+		{`with_entries(.value += 1)`, `jq error - assignment is not supported at position 20 in "with_entries(.value += 1)"`},
+		{`.a |= 1`, `jq error - assignment is not supported at position 3 in ".a |= 1"`},
+		{`.a //= 1`, `jq error - assignment is not supported at position 3 in ".a //= 1"`},
+		{`{(1): 2`, `jq error - expected "," at position 7 in "{(1): 2"`},
+		{`{(1)}`, `jq error - expected ":" at position 4 in "{(1)}"`},
+		{`"abc`, `jq error - unterminated string at position 0 in "\"abc"`},
+		{`"\q"`, `jq error - invalid escape sequence in string at position 1 in "\"\\q\""`},
+		{`"\ud83d\u0041"`, `jq error - unpaired surrogate in string at position 1 in "\"\\ud83d\\u0041\""`},
+		{`"\u12"`, `jq error - invalid unicode escape sequence in string at position 1 in "\"\\u12\""`},
+		{`then`, `jq error - unexpected keyword "then" at position 0 in "then"`},
+		{`.[:]`, `jq error - slice needs a start or an end at position 4 in ".[:]"`},
+		{`.a + "x"`, `jq error - array ([1,2,3]) and string ("x") cannot be added`},
+		{`.b.c.d`, `jq error - cannot index string with "d"`},
+		{`.a.b`, `jq error - cannot index array with "b"`},
+		{`.a[0][]`, `jq error - cannot iterate over number (1)`},
+		{`{(.a): 1}`, `jq error - object keys must be strings, not array ([1,2,3])`},
+		{`1 / 0`, `jq error - number (1) and number (0) cannot be divided because the divisor is zero`},
+		{`1e300 % 7`, `jq error - number (1e+300) and number (7) cannot be divided because they are beyond the range of integers`},
+		{`7 % -1e19`, `jq error - number (7) and number (-10000000000000000000) cannot be divided because they are beyond the range of integers`},
+		{`"x" * 1e19`, `jq error - repeat string result too long`},
+		{`"x" * 1e300`, `jq error - repeat string result too long`},
+		{`error("custom")`, `jq error - custom`},
+		{`error({a: 1})`, `jq error - {"a":1} (not a string)`},
+		{`try error("x") catch error("y: " + .)`, `jq error - y: x`},
+		{`(.a[] | .b?) , error("after")`, `jq error - after`},
+		{`(try .a) | error("downstream")`, `jq error - downstream`},
+	}
+
+	for i, tt := range tests {
+		_, err := runProgram(t, tt.src, `{"a": [1, 2, 3], "b": {"c": "x"}}`)
+		if err == nil {
+			t.Errorf("[test %d] error expected for %q.", i, tt.src)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("[test %d] unexpected error - got=%q, want=%q.", i, err.Error(), tt.expected)
+		}
+	}
+}
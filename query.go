@@ -0,0 +1,1167 @@
+package gj
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Query returns the values selected by the JSONPath query expr, as defined by RFC 9535, in document order.
+// For example:
+//
+//	$.phoneNumbers[?@.type == 'home'].number
+//	$..city
+//	$.store.book[0:5:2]
+//	$.store.book[?length(@.tags) > 1 && search(@.title, '[Gg]o')]
+//
+// Filter expressions support comparisons, &&, || and !, and the functions length, count, match, search and value.
+// The path of each value, as reported by Value.Path, uses the syntax of Get.
+// If expr is not a valid query, an error giving the position of the problem is returned.
+func (j *JSON) Query(expr string) ([]Value, error) {
+	q, err := parseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	root := queryNode{exp: j.json.Value}
+	nodes := q.eval(root, root)
+
+	values := make([]Value, 0, len(nodes))
+	for _, n := range nodes {
+		values = append(values, Value{exp: n.exp, path: n.path, opts: j.opts})
+	}
+
+	return values, nil
+}
+
+// queryNode is a value selected by a query, with its path.
+type queryNode struct {
+	exp  expression
+	path string
+}
+
+// query is a parsed JSONPath query, either absolute ($) or relative to the current node (@).
+type query struct {
+	relative bool
+	segments []querySegment
+}
+
+// querySegment is a child segment, or a descendant segment if descendant is set.
+type querySegment struct {
+	descendant bool
+	selectors  []querySelector
+}
+
+type selectorKind int
+
+const (
+	selectName selectorKind = iota
+	selectWildcard
+	selectIndex
+	selectSlice
+	selectFilter
+)
+
+// querySelector selects children of a node.
+type querySelector struct {
+	kind   selectorKind
+	name   string
+	index  int // also the start of a slice
+	end    int
+	step   int
+	hasIdx bool // whether a slice has a start
+	hasEnd bool // whether a slice has an end
+	filter filterExpr
+}
+
+// filterExpr is a logical expression of a filter selector.
+type filterExpr interface {
+	test(root, current queryNode) bool
+}
+
+type (
+	orExpr      struct{ operands []filterExpr }
+	andExpr     struct{ operands []filterExpr }
+	notExpr     struct{ operand filterExpr }
+	existExpr   struct{ q *query }
+	logicalCall struct{ call *functionCall }
+	compareExpr struct {
+		op          string
+		left, right *operand
+	}
+)
+
+// operandKind is the kind of an operand of a comparison or a function argument.
+type operandKind int
+
+const (
+	operandLiteral operandKind = iota
+	operandQuery
+	operandFunction
+)
+
+// operand is a literal, a query or a function call.
+type operand struct {
+	kind    operandKind
+	literal expression
+	q       *query
+	call    *functionCall
+	pos     int
+}
+
+// functionType is the type of a function result or parameter, as defined in RFC 9535, Section 2.4.1.
+type functionType int
+
+const (
+	valueType functionType = iota
+	logicalType
+	nodesType
+)
+
+// functionCall is a call of one of the function extensions.
+type functionCall struct {
+	name     string
+	args     []*operand
+	patterns map[string]*regexp.Regexp // compiled patterns of match and search, nil for invalid ones
+}
+
+// functions gives the parameter types and result type of each function extension.
+var functions = map[string]struct {
+	params []functionType
+	result functionType
+}{
+	"length": {[]functionType{valueType}, valueType},
+	"count":  {[]functionType{nodesType}, valueType},
+	"match":  {[]functionType{valueType, valueType}, logicalType},
+	"search": {[]functionType{valueType, valueType}, logicalType},
+	"value":  {[]functionType{nodesType}, valueType},
+}
+
+// maxQueryInt is the largest magnitude of an index or slice bound, from the I-JSON range.
+const maxQueryInt = 1<<53 - 1
+
+// queryParser parses a JSONPath query.
+type queryParser struct {
+	src string
+	pos int
+}
+
+// parseQuery parses the JSONPath query src.
+func parseQuery(src string) (*query, error) {
+	p := &queryParser{src: src}
+	if !p.consume("$") {
+		return nil, p.errorf(`expected "$"`)
+	}
+
+	q, err := p.parseSegments(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:p.pos+1])
+	}
+
+	return q, nil
+}
+
+// errorf returns an error at the current position.
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.pos, format, args...)
+}
+
+// errorAt returns an error at pos.
+func (p *queryParser) errorAt(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("query error - %s at position %d in %q", fmt.Sprintf(format, args...), pos, p.src)
+}
+
+// peek returns the current byte, or 0 at the end of the input.
+func (p *queryParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+// consume advances past s if the input continues with it.
+func (p *queryParser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// skipBlank skips spaces, tabs, line feeds and carriage returns.
+func (p *queryParser) skipBlank() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// parseSegments parses the segments following a root or current node identifier.
+func (p *queryParser) parseSegments(relative bool) (*query, error) {
+	q := &query{relative: relative}
+
+	for {
+		// Blank space is only allowed before a segment, so it is left alone if no segment follows.
+		start := p.pos
+		p.skipBlank()
+
+		var seg querySegment
+		var err error
+		switch {
+		case p.consume(".."):
+			seg, err = p.parseDescendant()
+		case p.consume("."):
+			seg, err = p.parseShorthand()
+		case p.peek() == '[':
+			seg, err = p.parseBracketed()
+		default:
+			p.pos = start
+			return q, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		q.segments = append(q.segments, seg)
+	}
+}
+
+// parseDescendant parses a descendant segment after "..".
+func (p *queryParser) parseDescendant() (querySegment, error) {
+	var seg querySegment
+	var err error
+	switch {
+	case p.peek() == '[':
+		seg, err = p.parseBracketed()
+	default:
+		seg, err = p.parseShorthand()
+	}
+	seg.descendant = true
+	return seg, err
+}
+
+// parseShorthand parses a wildcard or a member name after a dot.
+func (p *queryParser) parseShorthand() (querySegment, error) {
+	if p.consume("*") {
+		return querySegment{selectors: []querySelector{{kind: selectWildcard}}}, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		if !isNameChar(r) || (p.pos == start && r >= '0' && r <= '9') {
+			break
+		}
+		p.pos += size
+	}
+	if p.pos == start {
+		return querySegment{}, p.errorf("expected a member name")
+	}
+
+	return querySegment{selectors: []querySelector{{kind: selectName, name: p.src[start:p.pos]}}}, nil
+}
+
+// isNameChar reports whether r may appear in a member name shorthand.
+func isNameChar(r rune) bool {
+	switch {
+	case r == utf8.RuneError:
+		return false
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+		return true
+	}
+	return r >= 0x80 && (r < 0xd800 || r > 0xdfff)
+}
+
+// parseBracketed parses a bracketed selection.
+func (p *queryParser) parseBracketed() (querySegment, error) {
+	p.pos++ // [
+	var seg querySegment
+
+	for {
+		p.skipBlank()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return querySegment{}, err
+		}
+		seg.selectors = append(seg.selectors, sel)
+
+		p.skipBlank()
+		if p.consume("]") {
+			return seg, nil
+		}
+		if !p.consume(",") {
+			return querySegment{}, p.errorf(`expected "," or "]"`)
+		}
+	}
+}
+
+// parseSelector parses a single selector in a bracketed selection.
+func (p *queryParser) parseSelector() (querySelector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		name, err := p.parseString()
+		return querySelector{kind: selectName, name: name}, err
+	case c == '*':
+		p.pos++
+		return querySelector{kind: selectWildcard}, nil
+	case c == '?':
+		p.pos++
+		p.skipBlank()
+		expr, err := p.parseOr()
+		return querySelector{kind: selectFilter, filter: expr}, err
+	case c == '-' || c == ':' || (c >= '0' && c <= '9'):
+		return p.parseIndexOrSlice()
+	}
+
+	return querySelector{}, p.errorf("expected a selector")
+}
+
+// parseIndexOrSlice parses an index selector or a slice selector.
+func (p *queryParser) parseIndexOrSlice() (querySelector, error) {
+	sel := querySelector{kind: selectIndex, step: 1}
+
+	if p.peek() != ':' {
+		n, err := p.parseInt()
+		if err != nil {
+			return sel, err
+		}
+		sel.index, sel.hasIdx = n, true
+		p.skipBlank()
+	}
+	if !p.consume(":") {
+		return sel, nil
+	}
+
+	sel.kind = selectSlice
+	p.skipBlank()
+	if c := p.peek(); c == '-' || (c >= '0' && c <= '9') {
+		n, err := p.parseInt()
+		if err != nil {
+			return sel, err
+		}
+		sel.end, sel.hasEnd = n, true
+		p.skipBlank()
+	}
+	if p.consume(":") {
+		p.skipBlank()
+		if c := p.peek(); c == '-' || (c >= '0' && c <= '9') {
+			n, err := p.parseInt()
+			if err != nil {
+				return sel, err
+			}
+			sel.step = n
+		}
+	}
+
+	return sel, nil
+}
+
+// parseInt parses an integer without leading zeros, within the I-JSON range.
+func (p *queryParser) parseInt() (int, error) {
+	start := p.pos
+	p.consume("-")
+	digits := p.pos
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		p.pos++
+	}
+
+	literal := p.src[start:p.pos]
+	switch {
+	case p.pos == digits:
+		return 0, p.errorAt(start, "expected an integer")
+	case p.src[digits] == '0' && (p.pos-digits > 1 || digits > start):
+		return 0, p.errorAt(start, "invalid integer %q", literal)
+	}
+
+	n, err := strconv.ParseInt(literal, 10, 64)
+	if err != nil || n > maxQueryInt || n < -maxQueryInt {
+		return 0, p.errorAt(start, "integer %s out of range", literal)
+	}
+
+	return int(n), nil
+}
+
+// parseString parses a single- or double-quoted string literal.
+func (p *queryParser) parseString() (string, error) {
+	start := p.pos
+	quote := p.src[p.pos]
+	p.pos++
+
+	var out strings.Builder
+	for {
+		if p.pos >= len(p.src) {
+			return "", p.errorAt(start, "unterminated string")
+		}
+
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return out.String(), nil
+		case c < 0x20:
+			return "", p.errorf("control character in string")
+		case c != '\\':
+			out.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		p.pos++
+		switch e := p.peek(); e {
+		case 'b':
+			out.WriteByte('\b')
+		case 'f':
+			out.WriteByte('\f')
+		case 'n':
+			out.WriteByte('\n')
+		case 'r':
+			out.WriteByte('\r')
+		case 't':
+			out.WriteByte('\t')
+		case '/', '\\':
+			out.WriteByte(e)
+		case 'u':
+			r, err := p.parseUnicodeEscape()
+			if err != nil {
+				return "", err
+			}
+			out.WriteRune(r)
+			continue
+		default:
+			if e != quote {
+				return "", p.errorAt(p.pos-1, "invalid escape sequence in string")
+			}
+			out.WriteByte(e)
+		}
+		p.pos++
+	}
+}
+
+// parseUnicodeEscape parses a \uXXXX escape in a string literal of a query, starting at the u.
+func (p *queryParser) parseUnicodeEscape() (rune, error) {
+	start := p.pos - 1
+	p.pos++ // u
+	r, reason := decodeUnicodeEscape(p.peek, func() { p.pos++ })
+	if reason != "" {
+		return 0, p.errorAt(start, "%s in string", reason)
+	}
+	return r, nil
+}
+
+// parseOr parses a logical expression: operands separated by ||.
+func (p *queryParser) parseOr() (filterExpr, error) {
+	var operands []filterExpr
+	for {
+		expr, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, expr)
+
+		start := p.pos
+		p.skipBlank()
+		if !p.consume("||") {
+			p.pos = start
+			break
+		}
+		p.skipBlank()
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return &orExpr{operands: operands}, nil
+}
+
+// parseAnd parses basic expressions separated by &&.
+func (p *queryParser) parseAnd() (filterExpr, error) {
+	var operands []filterExpr
+	for {
+		expr, err := p.parseBasic()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, expr)
+
+		start := p.pos
+		p.skipBlank()
+		if !p.consume("&&") {
+			p.pos = start
+			break
+		}
+		p.skipBlank()
+	}
+
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return &andExpr{operands: operands}, nil
+}
+
+// parseBasic parses a parenthesized expression, a comparison or a test expression.
+func (p *queryParser) parseBasic() (filterExpr, error) {
+	if p.consume("!") {
+		p.skipBlank()
+		if p.peek() == '(' {
+			expr, err := p.parseParen()
+			return &notExpr{operand: expr}, err
+		}
+		pos := p.pos
+		op, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		expr, err := p.testExpr(op, pos)
+		return &notExpr{operand: expr}, err
+	}
+	if p.peek() == '(' {
+		return p.parseParen()
+	}
+
+	pos := p.pos
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	start := p.pos
+	p.skipBlank()
+	op := p.parseComparisonOp()
+	if op == "" {
+		p.pos = start
+		return p.testExpr(left, pos)
+	}
+
+	p.skipBlank()
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkComparable(left); err != nil {
+		return nil, err
+	}
+	if err := p.checkComparable(right); err != nil {
+		return nil, err
+	}
+
+	return &compareExpr{op: op, left: left, right: right}, nil
+}
+
+// parseParen parses a logical expression in parentheses.
+func (p *queryParser) parseParen() (filterExpr, error) {
+	p.pos++ // (
+	p.skipBlank()
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if !p.consume(")") {
+		return nil, p.errorf(`expected ")"`)
+	}
+	return expr, nil
+}
+
+// parseComparisonOp parses a comparison operator, returning "" if there is none.
+func (p *queryParser) parseComparisonOp() string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// testExpr returns the test expression for op, which must be a query or a function returning a logical value or nodes.
+func (p *queryParser) testExpr(op *operand, pos int) (filterExpr, error) {
+	switch op.kind {
+	case operandQuery:
+		return &existExpr{q: op.q}, nil
+	case operandFunction:
+		if functions[op.call.name].result != valueType {
+			return &logicalCall{call: op.call}, nil
+		}
+		return nil, p.errorAt(pos, "result of %s must be compared", op.call.name)
+	}
+	return nil, p.errorAt(pos, "literal must be compared")
+}
+
+// checkComparable checks that op can be compared: a literal, a singular query or a function returning a value.
+func (p *queryParser) checkComparable(op *operand) error {
+	switch op.kind {
+	case operandQuery:
+		if !op.q.singular() {
+			return p.errorAt(op.pos, "query in comparison must select at most one value")
+		}
+	case operandFunction:
+		if functions[op.call.name].result != valueType {
+			return p.errorAt(op.pos, "result of %s cannot be compared", op.call.name)
+		}
+	}
+	return nil
+}
+
+// parseOperand parses a literal, a query or a function call.
+func (p *queryParser) parseOperand() (*operand, error) {
+	pos := p.pos
+
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		q, err := p.parseSegments(c == '@')
+		return &operand{kind: operandQuery, q: q, pos: pos}, err
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		return &operand{kind: operandLiteral, literal: newString(s), pos: pos}, err
+	case c == '-' || (c >= '0' && c <= '9'):
+		exp, err := p.parseNumber()
+		return &operand{kind: operandLiteral, literal: exp, pos: pos}, err
+	}
+
+	for _, lit := range []string{"true", "false", "null"} {
+		if p.consume(lit) {
+			if lit == "null" {
+				return &operand{kind: operandLiteral, literal: newNull(), pos: pos}, nil
+			}
+			return &operand{kind: operandLiteral, literal: newBoolean(lit == "true"), pos: pos}, nil
+		}
+	}
+
+	for c := p.peek(); (c >= 'a' && c <= 'z') || (p.pos > pos && (c == '_' || (c >= '0' && c <= '9'))); c = p.peek() {
+		p.pos++
+	}
+	if p.pos == pos {
+		return nil, p.errorf("expected a comparison, a query or a function")
+	}
+	call, err := p.parseFunction(p.src[pos:p.pos], pos)
+	return &operand{kind: operandFunction, call: call, pos: pos}, err
+}
+
+// parseNumber parses a number literal.
+func (p *queryParser) parseNumber() (expression, error) {
+	start := p.pos
+	p.consume("-")
+	digits := p.pos
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		p.pos++
+	}
+	if p.pos == digits || (p.src[digits] == '0' && p.pos-digits > 1) {
+		return nil, p.errorAt(start, "invalid number")
+	}
+
+	isFloat := false
+	if p.consume(".") {
+		isFloat = true
+		frac := p.pos
+		for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+			p.pos++
+		}
+		if p.pos == frac {
+			return nil, p.errorAt(start, "invalid number")
+		}
+	}
+	if c := p.peek(); c == 'e' || c == 'E' {
+		isFloat = true
+		p.pos++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.pos++
+		}
+		exp := p.pos
+		for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+			p.pos++
+		}
+		if p.pos == exp {
+			return nil, p.errorAt(start, "invalid number")
+		}
+	}
+
+	literal := p.src[start:p.pos]
+	if !isFloat {
+		if n, err := strconv.ParseInt(literal, 10, 64); err == nil {
+			return newInteger(n), nil
+		}
+		// Larger integers keep their literal, so that they compare exactly.
+		return newNumber(literal), nil
+	}
+	f, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return nil, p.errorAt(start, "number %s out of range", literal)
+	}
+	return newFloat(f, 64), nil
+}
+
+// parseFunction parses the arguments of a call of the function name, found at pos.
+func (p *queryParser) parseFunction(name string, pos int) (*functionCall, error) {
+	fn, ok := functions[name]
+	if !ok {
+		return nil, p.errorAt(pos, "unknown function %q", name)
+	}
+	if !p.consume("(") {
+		return nil, p.errorf(`expected "("`)
+	}
+
+	call := &functionCall{name: name}
+	p.skipBlank()
+	for !p.consume(")") {
+		if len(call.args) > 0 {
+			if !p.consume(",") {
+				return nil, p.errorf(`expected "," or ")"`)
+			}
+			p.skipBlank()
+		}
+
+		arg, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if len(call.args) < len(fn.params) {
+			if err := p.checkArgument(arg, fn.params[len(call.args)], name); err != nil {
+				return nil, err
+			}
+		}
+		call.args = append(call.args, arg)
+		p.skipBlank()
+	}
+
+	if len(call.args) != len(fn.params) {
+		return nil, p.errorAt(pos, "%s expects %d argument(s), got %d", name, len(fn.params), len(call.args))
+	}
+
+	if name == "match" || name == "search" {
+		// A literal pattern is compiled once here, and others the first time they are seen.
+		call.patterns = map[string]*regexp.Regexp{}
+		if pattern, ok := call.args[1].literal.(*stringExpression); ok {
+			call.pattern(pattern.Value)
+		}
+	}
+
+	return call, nil
+}
+
+// checkArgument checks that arg is well-typed for a parameter of type t of the function name.
+func (p *queryParser) checkArgument(arg *operand, t functionType, name string) error {
+	switch t {
+	case valueType:
+		return p.checkComparable(arg)
+	case nodesType:
+		if arg.kind != operandQuery {
+			return p.errorAt(arg.pos, "argument of %s must be a query", name)
+		}
+	}
+	return nil
+}
+
+// singular reports whether q selects at most one node: it only has child segments with a single name or index.
+func (q *query) singular() bool {
+	for _, seg := range q.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		if k := seg.selectors[0].kind; k != selectName && k != selectIndex {
+			return false
+		}
+	}
+	return true
+}
+
+// eval returns the nodes selected by q.
+func (q *query) eval(root, current queryNode) []queryNode {
+	nodes := []queryNode{root}
+	if q.relative {
+		nodes = []queryNode{current}
+	}
+
+	for _, seg := range q.segments {
+		var next []queryNode
+		for _, n := range nodes {
+			if seg.descendant {
+				next = seg.descend(root, n, next)
+			} else {
+				next = seg.apply(root, n, next)
+			}
+		}
+		nodes = next
+	}
+
+	return nodes
+}
+
+// descend appends the result of applying the selectors of s to n and each of its descendants to nodes.
+func (s querySegment) descend(root, n queryNode, nodes []queryNode) []queryNode {
+	nodes = s.apply(root, n, nodes)
+	for _, c := range children(n) {
+		nodes = s.descend(root, c, nodes)
+	}
+	return nodes
+}
+
+// apply appends the nodes selected by each selector of s from n to nodes.
+func (s querySegment) apply(root, n queryNode, nodes []queryNode) []queryNode {
+	for _, sel := range s.selectors {
+		nodes = sel.apply(root, n, nodes)
+	}
+	return nodes
+}
+
+// apply appends the children of n selected by sel to nodes.
+func (sel querySelector) apply(root, n queryNode, nodes []queryNode) []queryNode {
+	switch sel.kind {
+	case selectName:
+		if obj, ok := n.exp.(*objectExpression); ok {
+			if value, ok := obj.get(sel.name); ok {
+				nodes = append(nodes, queryNode{exp: value, path: joinPath(n.path, sel.name)})
+			}
+		}
+	case selectWildcard:
+		nodes = append(nodes, children(n)...)
+	case selectIndex:
+		if arr, ok := n.exp.(*arrayExpression); ok {
+			i := sel.index
+			if i < 0 {
+				i += len(arr.Values)
+			}
+			if i >= 0 && i < len(arr.Values) {
+				nodes = append(nodes, queryNode{exp: arr.Values[i], path: indexPath(n.path, i)})
+			}
+		}
+	case selectSlice:
+		if arr, ok := n.exp.(*arrayExpression); ok {
+			for _, i := range sel.sliceIndices(len(arr.Values)) {
+				nodes = append(nodes, queryNode{exp: arr.Values[i], path: indexPath(n.path, i)})
+			}
+		}
+	case selectFilter:
+		for _, c := range children(n) {
+			if sel.filter.test(root, c) {
+				nodes = append(nodes, c)
+			}
+		}
+	}
+	return nodes
+}
+
+// sliceIndices returns the indices selected by the slice sel in an array of length n,
+// as defined in RFC 9535, Section 2.3.4.2.2.
+func (sel querySelector) sliceIndices(n int) []int {
+	if sel.step == 0 {
+		return nil
+	}
+
+	normalize := func(i int) int {
+		if i < 0 {
+			return n + i
+		}
+		return i
+	}
+	clamp := func(i, lo, hi int) int {
+		if i < lo {
+			return lo
+		}
+		if i > hi {
+			return hi
+		}
+		return i
+	}
+
+	start, end := 0, n
+	if sel.step < 0 {
+		start, end = n-1, -n-1
+	}
+	if sel.hasIdx {
+		start = sel.index
+	}
+	if sel.hasEnd {
+		end = sel.end
+	}
+	start, end = normalize(start), normalize(end)
+
+	var indices []int
+	if sel.step > 0 {
+		lower, upper := clamp(start, 0, n), clamp(end, 0, n)
+		for i := lower; i < upper; i += sel.step {
+			indices = append(indices, i)
+		}
+	} else {
+		upper, lower := clamp(start, -1, n-1), clamp(end, -1, n-1)
+		for i := upper; lower < i; i += sel.step {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// children returns the elements of the array n or the member values of the object n.
+func children(n queryNode) []queryNode {
+	var nodes []queryNode
+	switch exp := n.exp.(type) {
+	case *arrayExpression:
+		for i, value := range exp.Values {
+			nodes = append(nodes, queryNode{exp: value, path: indexPath(n.path, i)})
+		}
+	case *objectExpression:
+		for _, pair := range exp.Pairs {
+			nodes = append(nodes, queryNode{exp: pair.Value, path: joinPath(n.path, pair.Key.Value)})
+		}
+	}
+	return nodes
+}
+
+func (e *orExpr) test(root, current queryNode) bool {
+	for _, op := range e.operands {
+		if op.test(root, current) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *andExpr) test(root, current queryNode) bool {
+	for _, op := range e.operands {
+		if !op.test(root, current) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *notExpr) test(root, current queryNode) bool {
+	return !e.operand.test(root, current)
+}
+
+func (e *existExpr) test(root, current queryNode) bool {
+	return len(e.q.eval(root, current)) > 0
+}
+
+func (e *logicalCall) test(root, current queryNode) bool {
+	return e.call.logical(root, current)
+}
+
+func (e *compareExpr) test(root, current queryNode) bool {
+	left, right := e.left.value(root, current), e.right.value(root, current)
+
+	switch e.op {
+	case "==":
+		return queryEqual(left, right)
+	case "!=":
+		return !queryEqual(left, right)
+	case "<":
+		return queryLess(left, right)
+	case "<=":
+		return queryLess(left, right) || queryEqual(left, right)
+	case ">":
+		return queryLess(right, left)
+	case ">=":
+		return queryLess(right, left) || queryEqual(left, right)
+	}
+	return false
+}
+
+// value returns the value of the operand op, or nil if it has none.
+func (op *operand) value(root, current queryNode) expression {
+	switch op.kind {
+	case operandLiteral:
+		return op.literal
+	case operandQuery:
+		if nodes := op.q.eval(root, current); len(nodes) == 1 {
+			return nodes[0].exp
+		}
+		return nil
+	}
+	return op.call.value(root, current)
+}
+
+// value returns the result of a function returning a value, or nil if it has none.
+func (c *functionCall) value(root, current queryNode) expression {
+	switch c.name {
+	case "length":
+		switch arg := c.args[0].value(root, current).(type) {
+		case *stringExpression:
+			return newInteger(int64(utf8.RuneCountInString(arg.Value)))
+		case *arrayExpression:
+			return newInteger(int64(len(arg.Values)))
+		case *objectExpression:
+			return newInteger(int64(len(members(arg))))
+		}
+	case "count":
+		return newInteger(int64(len(c.args[0].q.eval(root, current))))
+	case "value":
+		if nodes := c.args[0].q.eval(root, current); len(nodes) == 1 {
+			return nodes[0].exp
+		}
+	}
+	return nil
+}
+
+// logical returns the result of a function returning a logical value.
+func (c *functionCall) logical(root, current queryNode) bool {
+	s, ok := c.args[0].value(root, current).(*stringExpression)
+	if !ok {
+		return false
+	}
+	pattern, ok := c.args[1].value(root, current).(*stringExpression)
+	if !ok {
+		return false
+	}
+
+	re := c.pattern(pattern.Value)
+	return re != nil && re.MatchString(s.Value)
+}
+
+// pattern returns the compiled I-Regexp pattern of match or search, or nil if it is not valid.
+// Compiled patterns are kept for the rest of the query.
+func (c *functionCall) pattern(pattern string) *regexp.Regexp {
+	if re, ok := c.patterns[pattern]; ok {
+		return re
+	}
+
+	expr := translateRegexp(pattern)
+	if c.name == "match" {
+		expr = `\A(?:` + expr + `)\z`
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		re = nil
+	}
+	c.patterns[pattern] = re
+	return re
+}
+
+// translateRegexp converts an I-Regexp (RFC 9485) to the syntax of the regexp package,
+// in which . also matches a carriage return and ^ and $ are anchors rather than ordinary characters.
+func translateRegexp(pattern string) string {
+	var out strings.Builder
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			out.WriteByte(c)
+			i++
+			c = pattern[i]
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '.' && !inClass:
+			out.WriteString(`[^\n\r]`)
+			continue
+		case (c == '^' || c == '$') && !inClass:
+			out.WriteByte('\\')
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
+
+// queryEqual reports whether a and b are equal, where nil stands for the absence of a value.
+func queryEqual(a, b expression) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	ka, kb := kindOf(a), kindOf(b)
+	if ka != kb {
+		return false
+	}
+
+	switch ka {
+	case KindNumber:
+		return compareNumbers(a, b) == 0
+	case KindString:
+		return a.(*stringExpression).Value == b.(*stringExpression).Value
+	case KindBoolean:
+		return a.(*booleanExpression).Value == b.(*booleanExpression).Value
+	case KindNull:
+		return true
+	case KindArray:
+		x, y := a.(*arrayExpression), b.(*arrayExpression)
+		if len(x.Values) != len(y.Values) {
+			return false
+		}
+		for i := range x.Values {
+			if !queryEqual(x.Values[i], y.Values[i]) {
+				return false
+			}
+		}
+		return true
+	case KindObject:
+		x, y := members(a.(*objectExpression)), members(b.(*objectExpression))
+		if len(x) != len(y) {
+			return false
+		}
+		for key, value := range x {
+			if !queryEqual(value, y[key]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// queryLess reports whether a is less than b. Only numbers and strings are ordered.
+func queryLess(a, b expression) bool {
+	if a == nil || b == nil {
+		return false
+	}
+
+	ka, kb := kindOf(a), kindOf(b)
+	switch {
+	case ka == KindNumber && kb == KindNumber:
+		return compareNumbers(a, b) < 0
+	case ka == KindString && kb == KindString:
+		return a.(*stringExpression).Value < b.(*stringExpression).Value
+	}
+	return false
+}
+
+// compareNumbers compares the numbers a and b, exactly if both are integers.
+func compareNumbers(a, b expression) int {
+	la, lb := numberLiteral(a), numberLiteral(b)
+
+	x, errX := strconv.ParseInt(la, 10, 64)
+	y, errY := strconv.ParseInt(lb, 10, 64)
+	if (errX != nil || errY != nil) && isIntegerLiteral(la) && isIntegerLiteral(lb) {
+		// Integers beyond int64, such as 64-bit IDs, are compared exactly.
+		bx, _ := new(big.Int).SetString(la, 10)
+		by, _ := new(big.Int).SetString(lb, 10)
+		return bx.Cmp(by)
+	}
+	if errX != nil || errY != nil {
+		fx, _ := strconv.ParseFloat(la, 64)
+		fy, _ := strconv.ParseFloat(lb, 64)
+		switch {
+		case fx < fy:
+			return -1
+		case fx > fy:
+			return 1
+		}
+		return 0
+	}
+
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// members returns the members of obj by key, keeping the last occurrence of repeated keys.
+func members(obj *objectExpression) map[string]expression {
+	m := make(map[string]expression, len(obj.Pairs))
+	for _, pair := range obj.Pairs {
+		m[pair.Key.Value] = pair.Value
+	}
+	return m
+}
@@ -0,0 +1,294 @@
+package gj
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The example document from RFC 9535, Section 1.5.
+const queryInput = `{ "store": {
+    "book": [
+      { "category": "reference",
+        "author": "Nigel Rees",
+        "title": "Sayings of the Century",
+        "price": 8.95
+      },
+      { "category": "fiction",
+        "author": "Evelyn Waugh",
+        "title": "Sword of Honour",
+        "price": 12.99
+      },
+      { "category": "fiction",
+        "author": "Herman Melville",
+        "title": "Moby Dick",
+        "isbn": "0-553-21311-3",
+        "price": 8.99
+      },
+      { "category": "fiction",
+        "author": "J. R. R. Tolkien",
+        "title": "The Lord of the Rings",
+        "isbn": "0-395-19395-8",
+        "price": 22.99
+      }
+    ],
+    "bicycle": {
+      "color": "red",
+      "price": 399
+    }
+  }
+}`
+
+func queryValues(t *testing.T, json *JSON, expr string) []interface{} {
+	values, err := json.Query(expr)
+	if err != nil {
+		t.Fatalf("unexpected error for %q - %q", expr, err)
+	}
+
+	got := []interface{}{}
+	for _, v := range values {
+		got = append(got, v.Interface())
+	}
+	return got
+}
+
+func TestQueryBookstore(t *testing.T) {
+	json, err := ParseString(queryInput)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	authors := []interface{}{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"}
+	tests := []struct {
+		expr     string
+		expected []interface{}
+	}{
+		{"$.store.book[*].author", authors},
+		{"$..author", authors},
+		{"$.store..price", []interface{}{8.95, 12.99, 8.99, 22.99, int64(399)}},
+		{"$..book[2].author", []interface{}{"Herman Melville"}},
+		{"$..book[2].publisher", []interface{}{}},
+		{"$..book[-1].title", []interface{}{"The Lord of the Rings"}},
+		{"$..book[0,1].title", []interface{}{"Sayings of the Century", "Sword of Honour"}},
+		{"$..book[:2].title", []interface{}{"Sayings of the Century", "Sword of Honour"}},
+		{"$..book[?@.isbn].title", []interface{}{"Moby Dick", "The Lord of the Rings"}},
+		{"$..book[?@.price<10].title", []interface{}{"Sayings of the Century", "Moby Dick"}},
+		{"$..book[?(@.category=='fiction' && @.price > 20)].author", []interface{}{"J. R. R. Tolkien"}},
+		{"$.store.bicycle['color', \"price\"]", []interface{}{"red", int64(399)}},
+		{"$ .store [ 'bicycle' ] .color", []interface{}{"red"}},
+	}
+
+	for i, tt := range tests {
+		got := queryValues(t, json, tt.expr)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("[test %d] values wrong - got=%#v, want=%#v.", i, got, tt.expected)
+		}
+	}
+
+	all, err := json.Query("$..*")
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if len(all) != 27 {
+		t.Errorf("number of descendants wrong - got=%d, want=%d.", len(all), 27)
+	}
+}
+
+func TestQueryPaths(t *testing.T) {
+	json, err := ParseString(`{"a": [{"b": 1}, {"b": 2}], "c.d": {"b": 3}}`)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	values, err := json.Query("$..b")
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	expected := []string{"a.[0].b", "a.[1].b", `c\.d.b`}
+	if len(values) != len(expected) {
+		t.Fatalf("number of values wrong - got=%d, want=%d.", len(values), len(expected))
+	}
+	for i, v := range values {
+		if v.Path() != expected[i] {
+			t.Errorf("[test %d] path wrong - got=%q, want=%q.", i, v.Path(), expected[i])
+		}
+		if got, err := json.Get(v.Path()); err != nil || got != v.Interface() {
+			t.Errorf("[test %d] Get of path wrong - got=%v, err=%v.", i, got, err)
+		}
+	}
+}
+
+func TestQuerySlices(t *testing.T) {
+	json, err := ParseString(`["a", "b", "c", "d", "e", "f", "g"]`)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"$[1:3]", "bc"},
+		{"$[5:]", "fg"},
+		{"$[1:5:2]", "bd"},
+		{"$[5:1:-2]", "fd"},
+		{"$[::-1]", "gfedcba"},
+		{"$[-2:]", "fg"},
+		{"$[:-5]", "ab"},
+		{"$[0:100]", "abcdefg"},
+		{"$[::0]", ""},
+		{"$[3:1]", ""},
+		{"$[0:5:2]", "ace"},
+		{"$[-1]", "g"},
+		{"$[7]", ""},
+		{"$[0, 0]", "aa"},
+	}
+
+	for i, tt := range tests {
+		var got strings.Builder
+		for _, v := range queryValues(t, json, tt.expr) {
+			got.WriteString(v.(string))
+		}
+		if got.String() != tt.expected {
+			t.Errorf("[test %d] values wrong - got=%q, want=%q.", i, got.String(), tt.expected)
+		}
+	}
+}
+
+func TestQueryFilters(t *testing.T) {
+	json, err := ParseString(`{
+		"phoneNumbers": [
+			{"type": "iPhone", "number": "0123-4567-8888"},
+			{"type": "home", "number": "0123-4567-8910"}
+		],
+		"items": [
+			{"name": "a", "v": 1, "tags": ["x", "y"]},
+			{"name": "b", "v": 2.0, "tags": []},
+			{"name": "c", "v": "2", "tags": ["x"]},
+			{"name": "d", "v": null, "obj": {"k": [1, 2]}},
+			{"name": "e\nf", "v": true, "obj": {"k": [1, 2]}}
+		],
+		"limit": 2,
+		"pattern": "^[a-c]$"
+	}`)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	tests := []struct {
+		expr     string
+		expected []interface{}
+	}{
+		{"$.phoneNumbers[?(@.type=='home')].number", []interface{}{"0123-4567-8910"}},
+		{"$.items[?@.v == 2].name", []interface{}{"b"}},
+		{"$.items[?@.v == '2'].name", []interface{}{"c"}},
+		{"$.items[?@.v >= 1].name", []interface{}{"a", "b"}},
+		{"$.items[?@.v <= $.limit].name", []interface{}{"a", "b"}},
+		{"$.items[?@.v != 2].name", []interface{}{"a", "c", "d", "e\nf"}},
+		{"$.items[?@.v == null].name", []interface{}{"d"}},
+		{"$.items[?@.v == true].name", []interface{}{"e\nf"}},
+		{"$.items[?@.missing == @.other].name", []interface{}{"a", "b", "c", "d", "e\nf"}},
+		{"$.items[?@.v > '1'].name", []interface{}{"c"}},
+		{"$.items[?@.obj.k == $.items[3].obj.k].name", []interface{}{"d", "e\nf"}},
+		{"$.items[?!@.tags].name", []interface{}{"d", "e\nf"}},
+		{"$.items[?!(@.v == 1 || @.v == 2)].name", []interface{}{"c", "d", "e\nf"}},
+		{"$.items[?@.v == 1 || @.v == 2 && @.name == 'c'].name", []interface{}{"a"}},
+		{"$.items[?length(@.tags) == 2].name", []interface{}{"a"}},
+		{"$.items[?length(@.name) == 3].name", []interface{}{"e\nf"}},
+		{"$.items[?count(@.tags[*]) == 1].name", []interface{}{"c"}},
+		{"$.items[?count(@..*) > 3].name", []interface{}{"a", "c", "d", "e\nf"}},
+		{"$.items[?match(@.name, '[a-c]')].name", []interface{}{"a", "b", "c"}},
+		{"$.items[?match(@.name, $.pattern)].name", []interface{}{}},
+		{"$.items[?match(@.name, 'e.f')].name", []interface{}{}},
+		{"$.items[?search(@.name, '[b-d]')].name", []interface{}{"b", "c", "d"}},
+		{"$.items[?search(@.name, '(')].name", []interface{}{}},
+		{"$.items[?search('abc', @.name)].name", []interface{}{"a", "b", "c"}},
+		{"$.items[?value(@..k[0]) == 1].name", []interface{}{"d", "e\nf"}},
+		{"$.items[?value(@.tags[*]) == 'x'].name", []interface{}{"c"}},
+		{"$.items[?@.tags[?@ == 'y']].name", []interface{}{"a"}},
+		{"$[?@ == 2]", []interface{}{int64(2)}},
+	}
+
+	for i, tt := range tests {
+		got := queryValues(t, json, tt.expr)
+		if tt.expected == nil {
+			tt.expected = []interface{}{}
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("[test %d] values wrong - got=%#v, want=%#v.", i, got, tt.expected)
+		}
+	}
+}
+
+func TestQueryPatterns(t *testing.T) {
+	tests := []struct {
+		expr     string
+		compiled int
+	}{
+		{"$[?match(@, 'a.')]", 1},
+		{"$[?search(@, '(')]", 1},
+		{"$[?search(@, @.p)]", 0},
+	}
+
+	for i, tt := range tests {
+		q, err := parseQuery(tt.expr)
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+		call := q.segments[0].selectors[0].filter.(*logicalCall).call
+		if len(call.patterns) != tt.compiled {
+			t.Errorf("[test %d] number of compiled patterns wrong - got=%d, want=%d.", i, len(call.patterns), tt.compiled)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	json, err := ParseString(`{"a": [1, 2]}`)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"", `query error - expected "$" at position 0 in ""`},
+		{"a", `query error - expected "$" at position 0 in "a"`},
+		{"$a", `query error - unexpected "a" at position 1 in "$a"`},
+		{" $", `query error - expected "$" at position 0 in " $"`},
+		{"$ ", `query error - unexpected " " at position 1 in "$ "`},
+		{"$. a", `query error - expected a member name at position 2 in "$. a"`},
+		{"$.1", `query error - expected a member name at position 2 in "$.1"`},
+		{"$[01]", `query error - invalid integer "01" at position 2 in "$[01]"`},
+		{"$[-0]", `query error - invalid integer "-0" at position 2 in "$[-0]"`},
+		{"$[9007199254740992]", `query error - integer 9007199254740992 out of range at position 2 in "$[9007199254740992]"`},
+		{"$[0", `query error - expected "," or "]" at position 3 in "$[0"`},
+		{"$[]", `query error - expected a selector at position 2 in "$[]"`},
+		{"$['a]", `query error - unterminated string at position 2 in "$['a]"`},
+		{`$['\a']`, `query error - invalid escape sequence in string at position 3 in "$['\\a']"`},
+		{`$['\ude00']`, `query error - unpaired surrogate in string at position 3 in "$['\\ude00']"`},
+		{"$[?@.a == [1]]", `query error - expected a comparison, a query or a function at position 10 in "$[?@.a == [1]]"`},
+		{"$[?@.a == 01]", `query error - invalid number at position 10 in "$[?@.a == 01]"`},
+		{"$[?1]", `query error - literal must be compared at position 3 in "$[?1]"`},
+		{"$[?@.a[*] == 1]", `query error - query in comparison must select at most one value at position 3 in "$[?@.a[*] == 1]"`},
+		{"$[?length(@)]", `query error - result of length must be compared at position 3 in "$[?length(@)]"`},
+		{"$[?match(@, 'a') == true]", `query error - result of match cannot be compared at position 3 in "$[?match(@, 'a') == true]"`},
+		{"$[?count(1) == 1]", `query error - argument of count must be a query at position 9 in "$[?count(1) == 1]"`},
+		{"$[?length(@.*) == 1]", `query error - query in comparison must select at most one value at position 10 in "$[?length(@.*) == 1]"`},
+		{"$[?length(@, @) == 1]", `query error - length expects 1 argument(s), got 2 at position 3 in "$[?length(@, @) == 1]"`},
+		{"$[?foo(@)]", `query error - unknown function "foo" at position 3 in "$[?foo(@)]"`},
+		{"$[?(@.a]", `query error - expected ")" at position 7 in "$[?(@.a]"`},
+	}
+
+	for i, tt := range tests {
+		_, err := json.Query(tt.expr)
+		if err == nil {
+			t.Errorf("[test %d] error expected.", i)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("[test %d] unexpected error - got=%q, want=%q.", i, err.Error(), tt.expected)
+		}
+	}
+}
@@ -30,10 +30,18 @@ const (
 	tokMinus = "-"
 )
 
-// TODO: Add position
+// Position represents a location in the input.
+type Position struct {
+	Offset int // byte offset, starting at 0
+	Line   int // line number, starting at 1
+	Column int // column number in characters, starting at 1
+}
+
 type token struct {
 	Type    tokenType
 	Literal string
+	Pos     Position
+	Err     *SyntaxError // first error found by the lexer while reading the token
 }
 
 var keywords = map[string]tokenType{
@@ -0,0 +1,138 @@
+package gj
+
+// GetString returns the string at path.
+// If the value is not a string, a *TypeMismatchError is returned.
+func (j *JSON) GetString(path string) (string, error) {
+	return j.at(path).Str()
+}
+
+// GetInt64 returns the integer at path.
+// If the value is not a number without a fraction or exponent, a *TypeMismatchError is returned,
+// and if it is an integer beyond the range of int64, an overflow error.
+func (j *JSON) GetInt64(path string) (int64, error) {
+	return j.at(path).Int64()
+}
+
+// GetUint64 returns the unsigned integer at path, such as a 64-bit ID beyond the range of int64.
+// If the value is not a non-negative number without a fraction or exponent, a *TypeMismatchError is returned.
+func (j *JSON) GetUint64(path string) (uint64, error) {
+	return j.at(path).Uint64()
+}
+
+// GetFloat64 returns the number at path as a float64, whatever the NumberMode.
+// If the value is not a number, a *TypeMismatchError is returned.
+func (j *JSON) GetFloat64(path string) (float64, error) {
+	return j.at(path).Float64()
+}
+
+// GetNumber returns the literal of the number at path, whatever the NumberMode.
+// If the value is not a number, a *TypeMismatchError is returned.
+func (j *JSON) GetNumber(path string) (Number, error) {
+	return j.at(path).Number()
+}
+
+// GetBool returns the boolean at path.
+// If the value is not a boolean, a *TypeMismatchError is returned.
+func (j *JSON) GetBool(path string) (bool, error) {
+	return j.at(path).Bool()
+}
+
+// GetArray returns the array at path, with its elements evaluated as by Get.
+// If the value is not an array, a *TypeMismatchError is returned.
+func (j *JSON) GetArray(path string) ([]interface{}, error) {
+	v := j.at(path)
+	if err := v.expect(KindArray); err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, 0, v.Len())
+	var err error
+	v.Each(func(i int, key string, elem Value) bool {
+		var value interface{}
+		value, err = elem.eval()
+		values = append(values, value)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// GetObject returns the object at path, with its members evaluated as by Get.
+// If the value is not an object, a *TypeMismatchError is returned.
+func (j *JSON) GetObject(path string) (map[string]interface{}, error) {
+	v := j.at(path)
+	if err := v.expect(KindObject); err != nil {
+		return nil, err
+	}
+
+	value, err := v.eval()
+	if err != nil {
+		return nil, err
+	}
+	return value.(map[string]interface{}), nil
+}
+
+// IsNull reports whether the value at path is null.
+func (j *JSON) IsNull(path string) (bool, error) {
+	v := j.at(path)
+	return v.Kind() == KindNull, v.Err()
+}
+
+// GetStringOr returns the string at path, or def if there is no string at path.
+func (j *JSON) GetStringOr(path string, def string) string {
+	if s, err := j.GetString(path); err == nil {
+		return s
+	}
+	return def
+}
+
+// GetInt64Or returns the integer at path, or def if there is no integer at path.
+func (j *JSON) GetInt64Or(path string, def int64) int64 {
+	if n, err := j.GetInt64(path); err == nil {
+		return n
+	}
+	return def
+}
+
+// GetUint64Or returns the unsigned integer at path, or def if there is no unsigned integer at path.
+func (j *JSON) GetUint64Or(path string, def uint64) uint64 {
+	if n, err := j.GetUint64(path); err == nil {
+		return n
+	}
+	return def
+}
+
+// GetFloat64Or returns the number at path, or def if there is no number at path.
+func (j *JSON) GetFloat64Or(path string, def float64) float64 {
+	if f, err := j.GetFloat64(path); err == nil {
+		return f
+	}
+	return def
+}
+
+// GetBoolOr returns the boolean at path, or def if there is no boolean at path.
+func (j *JSON) GetBoolOr(path string, def bool) bool {
+	if b, err := j.GetBool(path); err == nil {
+		return b
+	}
+	return def
+}
+
+// GetArrayOr returns the array at path, or def if there is no array at path.
+func (j *JSON) GetArrayOr(path string, def []interface{}) []interface{} {
+	if a, err := j.GetArray(path); err == nil {
+		return a
+	}
+	return def
+}
+
+// GetObjectOr returns the object at path, or def if there is no object at path.
+func (j *JSON) GetObjectOr(path string, def map[string]interface{}) map[string]interface{} {
+	if o, err := j.GetObject(path); err == nil {
+		return o
+	}
+	return def
+}
@@ -0,0 +1,121 @@
+package gj
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const typedInput = `{"s": "str", "i": 42, "n": -7, "f": 1.5, "e": 1e2, "t": true, "z": null, "a": [1, "x"], "o": {"k": 2}}`
+
+func TestTypedGetters(t *testing.T) {
+	json, err := ParseString(typedInput, WithNumbers(NumberFloat64))
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	tests := []struct {
+		get      func() (interface{}, error)
+		expected interface{}
+	}{
+		{func() (interface{}, error) { return json.GetString("s") }, "str"},
+		{func() (interface{}, error) { return json.GetInt64("i") }, int64(42)},
+		{func() (interface{}, error) { return json.GetInt64("n") }, int64(-7)},
+		{func() (interface{}, error) { return json.GetFloat64("f") }, 1.5},
+		{func() (interface{}, error) { return json.GetFloat64("e") }, 100.0},
+		{func() (interface{}, error) { return json.GetFloat64("n") }, -7.0},
+		{func() (interface{}, error) { return json.GetBool("t") }, true},
+		{func() (interface{}, error) { return json.GetArray("a") }, []interface{}{1.0, "x"}},
+		{func() (interface{}, error) { return json.GetObject("o") }, map[string]interface{}{"k": 2.0}},
+		{func() (interface{}, error) { return json.IsNull("z") }, true},
+		{func() (interface{}, error) { return json.IsNull("s") }, false},
+		{func() (interface{}, error) { return json.GetInt64("a.[0]") }, int64(1)},
+		{func() (interface{}, error) { return json.GetNumber("e") }, Number("1e2")},
+		{func() (interface{}, error) { return json.GetNumber("n") }, Number("-7")},
+	}
+
+	for i, tt := range tests {
+		got, err := tt.get()
+		if err != nil {
+			t.Errorf("[test %d] unexpected error - %q", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("[test %d] value wrong - got=%#v, want=%#v.", i, got, tt.expected)
+		}
+	}
+}
+
+func TestTypedGetterErrors(t *testing.T) {
+	json, err := ParseString(typedInput)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	tests := []struct {
+		get      func() error
+		expected string
+		mismatch bool
+	}{
+		{func() error { _, err := json.GetString("i"); return err }, `type error - "i" is number, expected string`, true},
+		{func() error { _, err := json.GetInt64("f"); return err }, `type error - "f" is number, expected integer`, true},
+		{func() error { _, err := json.GetInt64("e"); return err }, `type error - "e" is number, expected integer`, true},
+		{func() error { _, err := json.GetFloat64("s"); return err }, `type error - "s" is string, expected number`, true},
+		{func() error { _, err := json.GetBool("z"); return err }, `type error - "z" is null, expected boolean`, true},
+		{func() error { _, err := json.GetNumber("t"); return err }, `type error - "t" is boolean, expected number`, true},
+		{func() error { _, err := json.GetUint64("n"); return err }, `type error - "n" is number, expected unsigned integer`, true},
+		{func() error { _, err := json.GetArray("o"); return err }, `type error - "o" is object, expected array`, true},
+		{func() error { _, err := json.GetObject("a"); return err }, `type error - "a" is array, expected object`, true},
+		{func() error { _, err := json.GetString("a.[1].x"); return err }, `key error - "x"`, false},
+		{func() error { _, err := json.IsNull("missing"); return err }, `key error - "missing"`, false},
+	}
+
+	for i, tt := range tests {
+		err := tt.get()
+		if err == nil {
+			t.Errorf("[test %d] expected error - want=%q.", i, tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("[test %d] unexpected error - got=%q, want=%q.", i, err, tt.expected)
+		}
+		var mismatch *TypeMismatchError
+		if errors.As(err, &mismatch) != tt.mismatch {
+			t.Errorf("[test %d] error type wrong - got=%T.", i, err)
+		}
+	}
+}
+
+func TestTypedGettersOr(t *testing.T) {
+	json, err := ParseString(typedInput)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	tests := []struct {
+		got      interface{}
+		expected interface{}
+	}{
+		{json.GetStringOr("s", "def"), "str"},
+		{json.GetStringOr("i", "def"), "def"},
+		{json.GetStringOr("missing", "def"), "def"},
+		{json.GetInt64Or("i", -1), int64(42)},
+		{json.GetInt64Or("f", -1), int64(-1)},
+		{json.GetUint64Or("i", 0), uint64(42)},
+		{json.GetUint64Or("n", 0), uint64(0)},
+		{json.GetFloat64Or("f", -1), 1.5},
+		{json.GetFloat64Or("t", -1), -1.0},
+		{json.GetBoolOr("t", false), true},
+		{json.GetBoolOr("z", false), false},
+		{json.GetArrayOr("a", nil), []interface{}{int64(1), "x"}},
+		{json.GetArrayOr("s", []interface{}{}), []interface{}{}},
+		{json.GetObjectOr("o", nil), map[string]interface{}{"k": int64(2)}},
+		{json.GetObjectOr("a.[5]", nil), map[string]interface{}(nil)},
+	}
+
+	for i, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.expected) {
+			t.Errorf("[test %d] value wrong - got=%#v, want=%#v.", i, tt.got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package gj
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Kind is the JSON type of a Value.
+type Kind int
+
+const (
+	KindInvalid Kind = iota // the Value does not exist
+	KindNull
+	KindBoolean
+	KindNumber
+	KindString
+	KindArray
+	KindObject
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindBoolean:
+		return "boolean"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindArray:
+		return "array"
+	case KindObject:
+		return "object"
+	}
+	return "invalid"
+}
+
+// kindOf returns the JSON type of exp.
+func kindOf(exp expression) Kind {
+	switch exp.(type) {
+	case *objectExpression:
+		return KindObject
+	case *arrayExpression:
+		return KindArray
+	case *stringExpression:
+		return KindString
+	case *booleanExpression:
+		return KindBoolean
+	case *nullExpression:
+		return KindNull
+	}
+	return KindNumber
+}
+
+// Value is a handle to a value in a JSON document, used to navigate it step by step.
+// Navigating to a value that does not exist gives an invalid Value, which carries the error
+// and can still be navigated further; the error is reported by Err and by the conversion methods.
+// A Value shares the document, so it sees changes made below it with Set and the other mutations.
+type Value struct {
+	exp  expression
+	path string
+	opts *options
+	err  error
+}
+
+// Root returns the top-level value of the JSON.
+func (j *JSON) Root() Value {
+	return Value{exp: j.json.Value, opts: j.opts}
+}
+
+// at returns the value at path.
+func (j *JSON) at(path string) Value {
+	exp, err := j.find(path)
+	return Value{exp: exp, path: path, opts: j.opts, err: err}
+}
+
+// Key returns the member named name of the object v.
+// If name appears more than once, the last occurrence is returned.
+func (v Value) Key(name string) Value {
+	return v.step(pathSegment{key: name}, joinPath(v.path, name))
+}
+
+// Index returns the i-th element of the array v. Negative indices count from the end.
+func (v Value) Index(i int) Value {
+	if arr, ok := v.exp.(*arrayExpression); ok && v.err == nil {
+		if n, ok := resolveIndex(arr, i); ok {
+			i = n
+		}
+	}
+	return v.step(pathSegment{index: i, isIndex: true}, indexPath(v.path, i))
+}
+
+// step returns the child of v selected by seg, found at path.
+func (v Value) step(seg pathSegment, path string) Value {
+	if v.err != nil {
+		return v
+	}
+
+	exp, err := child(v.exp, seg)
+	return Value{exp: exp, path: path, opts: v.opts, err: err}
+}
+
+// Err returns the error that made v invalid, or nil if v exists.
+func (v Value) Err() error {
+	return v.err
+}
+
+// Exists reports whether v exists.
+func (v Value) Exists() bool {
+	return v.err == nil
+}
+
+// Path returns the path of v from the root, in the syntax used by Get.
+func (v Value) Path() string {
+	return v.path
+}
+
+// Kind returns the JSON type of v, or KindInvalid if v does not exist.
+func (v Value) Kind() Kind {
+	if v.err != nil {
+		return KindInvalid
+	}
+	return kindOf(v.exp)
+}
+
+// Len returns the number of elements of an array or members of an object, and 0 for other values.
+func (v Value) Len() int {
+	switch exp := v.exp.(type) {
+	case *arrayExpression:
+		if v.err == nil {
+			return len(exp.Values)
+		}
+	case *objectExpression:
+		if v.err == nil {
+			return len(exp.Pairs)
+		}
+	}
+	return 0
+}
+
+// Keys returns the keys of the object v in the order they appear in the input, or nil if v is not an object.
+func (v Value) Keys() []string {
+	obj, ok := v.exp.(*objectExpression)
+	if !ok || v.err != nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(obj.Pairs))
+	for _, pair := range obj.Pairs {
+		keys = append(keys, pair.Key.Value)
+	}
+
+	return keys
+}
+
+// Each calls fn for each element of the array v or each member of the object v, in order.
+// For array elements key is empty; for object members i is the position of the member.
+// Iteration stops when fn returns false.
+func (v Value) Each(fn func(i int, key string, value Value) bool) {
+	if v.err != nil {
+		return
+	}
+
+	switch exp := v.exp.(type) {
+	case *arrayExpression:
+		for i, elem := range exp.Values {
+			if !fn(i, "", Value{exp: elem, path: indexPath(v.path, i), opts: v.opts}) {
+				return
+			}
+		}
+	case *objectExpression:
+		for i, pair := range exp.Pairs {
+			if !fn(i, pair.Key.Value, Value{exp: pair.Value, path: joinPath(v.path, pair.Key.Value), opts: v.opts}) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the value of the string v.
+// For other values it returns their JSON text, and for an invalid Value an empty string.
+func (v Value) String() string {
+	if v.err != nil {
+		return ""
+	}
+	if s, ok := v.exp.(*stringExpression); ok {
+		return s.Value
+	}
+	return v.exp.String()
+}
+
+// Str returns the value of the string v, or a *TypeMismatchError if v is not a string.
+func (v Value) Str() (string, error) {
+	if err := v.expect(KindString); err != nil {
+		return "", err
+	}
+	return v.exp.(*stringExpression).Value, nil
+}
+
+// Int64 returns the value of v, or a *TypeMismatchError if v is not a number without a fraction or exponent.
+// An integer beyond the range of int64 is reported as overflowing it.
+func (v Value) Int64() (int64, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+
+	switch exp := v.exp.(type) {
+	case *integerExpression:
+		return exp.Value, nil
+	case *prefixExpression:
+		if right, ok := exp.Right.(*integerExpression); ok {
+			return -right.Value, nil
+		}
+	}
+	if literal := numberLiteral(v.exp); v.Kind() == KindNumber && isIntegerLiteral(literal) {
+		return 0, fmt.Errorf("value error - %s overflows int64", literal)
+	}
+
+	return 0, &TypeMismatchError{Path: v.path, Expected: "integer", Actual: v.Kind().String()}
+}
+
+// Uint64 returns the value of v, or a *TypeMismatchError if v is not a non-negative number
+// without a fraction or exponent that fits in uint64.
+func (v Value) Uint64() (uint64, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+
+	if v.Kind() == KindNumber {
+		if n, err := strconv.ParseUint(numberLiteral(v.exp), 10, 64); err == nil {
+			return n, nil
+		}
+	}
+
+	return 0, &TypeMismatchError{Path: v.path, Expected: "unsigned integer", Actual: v.Kind().String()}
+}
+
+// Float64 returns the value of the number v as a float64, whatever the NumberMode,
+// or a *TypeMismatchError if v is not a number.
+func (v Value) Float64() (float64, error) {
+	if err := v.expect(KindNumber); err != nil {
+		return 0, err
+	}
+	f, _ := strconv.ParseFloat(numberLiteral(v.exp), 64)
+	return f, nil
+}
+
+// Number returns the literal of the number v, whatever the NumberMode,
+// or a *TypeMismatchError if v is not a number.
+func (v Value) Number() (Number, error) {
+	if err := v.expect(KindNumber); err != nil {
+		return "", err
+	}
+	return Number(numberLiteral(v.exp)), nil
+}
+
+// Bool returns the value of the boolean v, or a *TypeMismatchError if v is not a boolean.
+func (v Value) Bool() (bool, error) {
+	if err := v.expect(KindBoolean); err != nil {
+		return false, err
+	}
+	return v.exp.(*booleanExpression).Value, nil
+}
+
+// Interface returns v evaluated as by Get, or nil if v does not exist or cannot be evaluated.
+func (v Value) Interface() interface{} {
+	value, _ := v.eval()
+	return value
+}
+
+// eval returns v evaluated as by Get, or the error of v or of its evaluation.
+func (v Value) eval() (interface{}, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	return evalExpression(v.exp, v.opts.numbers)
+}
+
+// expect returns the error of v, or a *TypeMismatchError if v is not of the given kind.
+func (v Value) expect(kind Kind) error {
+	if v.err != nil {
+		return v.err
+	}
+	if actual := v.Kind(); actual != kind {
+		return &TypeMismatchError{Path: v.path, Expected: kind.String(), Actual: actual.String()}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package gj
+
+import (
+	"reflect"
+	"testing"
+)
+
+const valueInput = `{
+  "address": {"city": "New York", "zip": null},
+  "phoneNumbers": [
+    {"type": "home", "number": "212 555-1234"},
+    {"type": "office", "number": "646 555-4567"}
+  ],
+  "age": 27,
+  "height": 1.8,
+  "isAlive": true
+}`
+
+func TestValueNavigation(t *testing.T) {
+	json, err := ParseString(valueInput)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	root := json.Root()
+
+	tests := []struct {
+		value  Value
+		kind   Kind
+		path   string
+		str    string
+		length int
+	}{
+		{root, KindObject, "", `{"address": {"city": "New York", "zip": null}, "phoneNumbers": [{"type": "home", "number": "212 555-1234"}, {"type": "office", "number": "646 555-4567"}], "age": 27, "height": 1.8, "isAlive": true}`, 5},
+		{root.Key("address").Key("city"), KindString, "address.city", "New York", 0},
+		{root.Key("address").Key("zip"), KindNull, "address.zip", "null", 0},
+		{root.Key("phoneNumbers"), KindArray, "phoneNumbers", `[{"type": "home", "number": "212 555-1234"}, {"type": "office", "number": "646 555-4567"}]`, 2},
+		{root.Key("phoneNumbers").Index(1).Key("number"), KindString, "phoneNumbers.[1].number", "646 555-4567", 0},
+		{root.Key("phoneNumbers").Index(-2).Key("type"), KindString, "phoneNumbers.[0].type", "home", 0},
+		{root.Key("age"), KindNumber, "age", "27", 0},
+		{root.Key("isAlive"), KindBoolean, "isAlive", "true", 0},
+		{root.Key("missing").Key("city"), KindInvalid, "missing", "", 0},
+		{root.Key("phoneNumbers").Index(2).Key("type"), KindInvalid, "phoneNumbers.[2]", "", 0},
+	}
+
+	for i, tt := range tests {
+		if tt.value.Kind() != tt.kind {
+			t.Errorf("[test %d] kind wrong - got=%s, want=%s.", i, tt.value.Kind(), tt.kind)
+		}
+		if tt.value.Path() != tt.path {
+			t.Errorf("[test %d] path wrong - got=%q, want=%q.", i, tt.value.Path(), tt.path)
+		}
+		if tt.value.String() != tt.str {
+			t.Errorf("[test %d] string wrong - got=%q, want=%q.", i, tt.value.String(), tt.str)
+		}
+		if tt.value.Len() != tt.length {
+			t.Errorf("[test %d] length wrong - got=%d, want=%d.", i, tt.value.Len(), tt.length)
+		}
+		if tt.value.Exists() != (tt.kind != KindInvalid) {
+			t.Errorf("[test %d] existence wrong - got=%t.", i, tt.value.Exists())
+		}
+	}
+}
+
+func TestValueConversions(t *testing.T) {
+	json, err := ParseString(valueInput)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	root := json.Root()
+
+	if n, err := root.Key("age").Int64(); err != nil || n != 27 {
+		t.Errorf("Int64 wrong - got=%d, err=%v.", n, err)
+	}
+	if f, err := root.Key("height").Float64(); err != nil || f != 1.8 {
+		t.Errorf("Float64 wrong - got=%v, err=%v.", f, err)
+	}
+	if b, err := root.Key("isAlive").Bool(); err != nil || !b {
+		t.Errorf("Bool wrong - got=%t, err=%v.", b, err)
+	}
+	if s, err := root.Key("address").Key("city").Str(); err != nil || s != "New York" {
+		t.Errorf("Str wrong - got=%q, err=%v.", s, err)
+	}
+
+	expected := map[string]interface{}{"city": "New York", "zip": nil}
+	if got := root.Key("address").Interface(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Interface wrong - got=%#v, want=%#v.", got, expected)
+	}
+
+	errTests := []struct {
+		err      error
+		expected string
+	}{
+		{func() error { _, err := root.Key("height").Int64(); return err }(), `type error - "height" is number, expected integer`},
+		{func() error { _, err := root.Key("address").Str(); return err }(), `type error - "address" is object, expected string`},
+		{func() error { _, err := root.Key("age").Index(0).Bool(); return err }(), `index error - cannot use "[]"`},
+		{func() error { _, err := root.Key("nope").Float64(); return err }(), `key error - "nope"`},
+		{root.Key("phoneNumbers").Index(-3).Err(), "index error - index out of bounds"},
+	}
+
+	for i, tt := range errTests {
+		if tt.err == nil || tt.err.Error() != tt.expected {
+			t.Errorf("[test %d] unexpected error - got=%v, want=%q.", i, tt.err, tt.expected)
+		}
+	}
+}
+
+func TestValueEach(t *testing.T) {
+	json, err := ParseString(valueInput)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	root := json.Root()
+
+	if keys := root.Keys(); !reflect.DeepEqual(keys, []string{"address", "phoneNumbers", "age", "height", "isAlive"}) {
+		t.Errorf("keys wrong - got=%v.", keys)
+	}
+
+	var types []string
+	root.Key("phoneNumbers").Each(func(i int, key string, value Value) bool {
+		types = append(types, value.Key("type").String()+"@"+value.Path())
+		return true
+	})
+	if !reflect.DeepEqual(types, []string{"home@phoneNumbers.[0]", "office@phoneNumbers.[1]"}) {
+		t.Errorf("array iteration wrong - got=%v.", types)
+	}
+
+	var members []string
+	root.Key("address").Each(func(i int, key string, value Value) bool {
+		members = append(members, key)
+		return false
+	})
+	if !reflect.DeepEqual(members, []string{"city"}) {
+		t.Errorf("object iteration wrong - got=%v.", members)
+	}
+
+	if err := json.Set("address.city", "Boston"); err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+	if got := root.Key("address").Key("city").String(); got != "Boston" {
+		t.Errorf("value does not see changes - got=%q.", got)
+	}
+}
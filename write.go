@@ -0,0 +1,270 @@
+package gj
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// FormatOption configures the output of Indent and Compact.
+type FormatOption func(*formatOptions)
+
+// formatOptions holds the settings used to write expressions.
+type formatOptions struct {
+	prefix          string
+	indent          string
+	indented        bool
+	comma           string // separator between members and elements on a single line
+	colon           string // separator between keys and values
+	sortKeys        bool
+	trailingNewline bool
+	asciiOnly       bool
+}
+
+// defaultFormat is the format of String and WriteTo.
+var defaultFormat = &formatOptions{comma: ", ", colon: ": "}
+
+// SortKeys writes the members of objects sorted by key instead of in input order.
+func SortKeys() FormatOption {
+	return func(o *formatOptions) {
+		o.sortKeys = true
+	}
+}
+
+// TrailingNewline ends the output with a newline.
+func TrailingNewline() FormatOption {
+	return func(o *formatOptions) {
+		o.trailingNewline = true
+	}
+}
+
+// ASCIIOnly escapes every non-ASCII character in strings and keys as \uXXXX, using surrogate pairs where needed.
+func ASCIIOnly() FormatOption {
+	return func(o *formatOptions) {
+		o.asciiOnly = true
+	}
+}
+
+// WriteTo writes the JSON to w in the same form as String and returns the number of bytes written.
+// Strings and keys are escaped as required by RFC 8259, so the output can always be parsed again
+// and yields an equal document. Invalid UTF-8 in strings is written as the replacement character U+FFFD.
+func (j *JSON) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	writeExpression(bw, j.json.Value, defaultFormat)
+	err := bw.Flush()
+
+	return cw.n, err
+}
+
+// Indent returns the JSON with each member and element on its own line.
+// Each line after the first begins with prefix followed by one copy of indent per nesting level.
+// Empty objects and arrays are written as {} and [].
+func (j *JSON) Indent(prefix, indent string, opts ...FormatOption) string {
+	f := &formatOptions{prefix: prefix, indent: indent, indented: true, colon: ": "}
+	return j.format(f, opts)
+}
+
+// Compact returns the JSON without any insignificant whitespace.
+func (j *JSON) Compact(opts ...FormatOption) string {
+	f := &formatOptions{comma: ",", colon: ":"}
+	return j.format(f, opts)
+}
+
+// format returns the JSON written with f, after applying opts.
+func (j *JSON) format(f *formatOptions, opts []FormatOption) string {
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	var out bytes.Buffer
+	writeExpression(&out, j.json.Value, f)
+	if f.trailingNewline {
+		out.WriteByte('\n')
+	}
+
+	return out.String()
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// byteWriter is the subset of *bufio.Writer and *bytes.Buffer used to write expressions.
+// Write errors are kept by the underlying writer and reported when it is flushed.
+type byteWriter interface {
+	io.Writer
+	io.ByteWriter
+	io.StringWriter
+}
+
+// format returns exp written in the default format.
+func format(exp expression) string {
+	var out bytes.Buffer
+	writeExpression(&out, exp, defaultFormat)
+	return out.String()
+}
+
+// writeExpression writes exp to w using f.
+func writeExpression(w byteWriter, exp expression, f *formatOptions) {
+	writeValue(w, exp, f, 0)
+}
+
+// writeValue writes exp, nested depth levels deep, to w using f.
+func writeValue(w byteWriter, exp expression, f *formatOptions, depth int) {
+	switch exp := exp.(type) {
+	case *stringExpression:
+		writeString(w, exp.Value, f.asciiOnly)
+	case *prefixExpression:
+		w.WriteString(exp.Operator)
+		writeValue(w, exp.Right, f, depth)
+	case *objectExpression:
+		pairs := exp.Pairs
+		if f.sortKeys {
+			pairs = make([]*objectPair, len(exp.Pairs))
+			copy(pairs, exp.Pairs)
+			sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Key.Value < pairs[j].Key.Value })
+		}
+
+		w.WriteByte('{')
+		for i, pair := range pairs {
+			writeSeparator(w, f, i, depth+1)
+			writeString(w, pair.Key.Value, f.asciiOnly)
+			w.WriteString(f.colon)
+			writeValue(w, pair.Value, f, depth+1)
+		}
+		if len(pairs) > 0 {
+			writeNewline(w, f, depth)
+		}
+		w.WriteByte('}')
+	case *arrayExpression:
+		w.WriteByte('[')
+		for i, value := range exp.Values {
+			writeSeparator(w, f, i, depth+1)
+			writeValue(w, value, f, depth+1)
+		}
+		if len(exp.Values) > 0 {
+			writeNewline(w, f, depth)
+		}
+		w.WriteByte(']')
+	default:
+		w.WriteString(exp.TokenLiteral())
+	}
+}
+
+// writeSeparator writes what precedes the i-th member or element, nested depth levels deep.
+func writeSeparator(w byteWriter, f *formatOptions, i, depth int) {
+	if f.indented {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		writeNewline(w, f, depth)
+	} else if i > 0 {
+		w.WriteString(f.comma)
+	}
+}
+
+// writeNewline starts a new line indented depth levels, if f is indented.
+func writeNewline(w byteWriter, f *formatOptions, depth int) {
+	if !f.indented {
+		return
+	}
+	w.WriteByte('\n')
+	w.WriteString(f.prefix)
+	for i := 0; i < depth; i++ {
+		w.WriteString(f.indent)
+	}
+}
+
+const hex = "0123456789abcdef"
+
+// writeString writes s to w surrounded by double quotes, escaping the characters that RFC 8259
+// requires to be escaped, and every non-ASCII character if asciiOnly is set. Invalid UTF-8 is replaced with U+FFFD.
+func writeString(w byteWriter, s string, asciiOnly bool) {
+	w.WriteByte('"')
+
+	start := 0
+	for i := 0; i < len(s); {
+		ch := s[i]
+		if ch >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				w.WriteString(s[start:i])
+				w.WriteString(`\ufffd`)
+				i += size
+				start = i
+				continue
+			}
+			if asciiOnly {
+				w.WriteString(s[start:i])
+				writeRune(w, r)
+				i += size
+				start = i
+				continue
+			}
+			i += size
+			continue
+		}
+		if ch >= 0x20 && ch != '"' && ch != '\\' {
+			i++
+			continue
+		}
+
+		w.WriteString(s[start:i])
+		switch ch {
+		case '"', '\\':
+			w.WriteByte('\\')
+			w.WriteByte(ch)
+		case '\b':
+			w.WriteString(`\b`)
+		case '\f':
+			w.WriteString(`\f`)
+		case '\n':
+			w.WriteString(`\n`)
+		case '\r':
+			w.WriteString(`\r`)
+		case '\t':
+			w.WriteString(`\t`)
+		default:
+			w.WriteString(`\u00`)
+			w.WriteByte(hex[ch>>4])
+			w.WriteByte(hex[ch&0xf])
+		}
+		i++
+		start = i
+	}
+	w.WriteString(s[start:])
+
+	w.WriteByte('"')
+}
+
+// writeRune writes r as one or two \uXXXX escapes.
+func writeRune(w byteWriter, r rune) {
+	if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+		writeEscape(w, r1)
+		writeEscape(w, r2)
+		return
+	}
+	writeEscape(w, r)
+}
+
+// writeEscape writes the UTF-16 code unit r as a \uXXXX escape.
+func writeEscape(w byteWriter, r rune) {
+	w.WriteString(`\u`)
+	w.WriteByte(hex[r>>12&0xf])
+	w.WriteByte(hex[r>>8&0xf])
+	w.WriteByte(hex[r>>4&0xf])
+	w.WriteByte(hex[r&0xf])
+}
@@ -0,0 +1,181 @@
+package gj
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{}`, `{}`},
+		{`[]`, `[]`},
+		{`[1, -2, 3.5e-1, -0.0, true, false, null]`, `[1, -2, 3.5e-1, -0.0, true, false, null]`},
+		{`{"say \"hi\"": "a\\b"}`, `{"say \"hi\"": "a\\b"}`},
+		{`{"\n\u0001": "\t\u001f"}`, `{"\n\u0001": "\t\u001f"}`},
+		{`{"é ": "😀/"}`, "{\"é \": \"😀/\"}"},
+		{"[\"a\xffb\"]", `["a\ufffdb"]`},
+	}
+
+	for i, tt := range tests {
+		json, err := ParseString(tt.input)
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+
+		var out bytes.Buffer
+		n, err := json.WriteTo(&out)
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("[test %d] output wrong - got=%s, want=%s.", i, out.String(), tt.expected)
+		}
+		if n != int64(out.Len()) {
+			t.Errorf("[test %d] count wrong - got=%d, want=%d.", i, n, out.Len())
+		}
+		if json.String() != tt.expected {
+			t.Errorf("[test %d] json.String() wrong - got=%s, want=%s.", i, json.String(), tt.expected)
+		}
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	values := []interface{}{
+		"",
+		"\"\\/\b\f\n\r\t",
+		"\x00\x01\x7f",
+		"日本語 😀  ",
+		map[string]interface{}{"\"": "\\", "\x1f": []interface{}{"\t", int64(-1), 0.5}},
+		[]interface{}{map[string]interface{}{}, []interface{}{}, nil, true},
+	}
+
+	for i, v := range values {
+		json, err := FromValue(v)
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+
+		parsed, err := ParseString(json.String(), WithMode(ModeStrict))
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+		if parsed.String() != json.String() {
+			t.Errorf("[test %d] reparsed document wrong - got=%s, want=%s.", i, parsed, json)
+		}
+
+		var got interface{}
+		if err := parsed.Decode(&got); err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+		if format, _ := FromValue(got); format.String() != json.String() {
+			t.Errorf("[test %d] decoded value wrong - got=%s, want=%s.", i, format, json)
+		}
+	}
+}
+
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		return w.n, errors.New("disk full")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestWriteToError(t *testing.T) {
+	json, err := ParseString(`["` + string(bytes.Repeat([]byte("a"), 8192)) + `"]`)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	n, err := json.WriteTo(&failingWriter{n: 100})
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("unexpected error - got=%v, want=%q.", err, "disk full")
+	}
+	if n != 100 {
+		t.Errorf("count wrong - got=%d, want=%d.", n, 100)
+	}
+}
+
+func TestIndent(t *testing.T) {
+	input := `{"b": [1, {"c": null}], "a": {}, "d": []}`
+
+	tests := []struct {
+		prefix   string
+		indent   string
+		opts     []FormatOption
+		expected string
+	}{
+		{"", "  ", nil, "{\n  \"b\": [\n    1,\n    {\n      \"c\": null\n    }\n  ],\n  \"a\": {},\n  \"d\": []\n}"},
+		{"> ", "\t", nil, "{\n> \t\"b\": [\n> \t\t1,\n> \t\t{\n> \t\t\t\"c\": null\n> \t\t}\n> \t],\n> \t\"a\": {},\n> \t\"d\": []\n> }"},
+		{"", " ", []FormatOption{SortKeys(), TrailingNewline()}, "{\n \"a\": {},\n \"b\": [\n  1,\n  {\n   \"c\": null\n  }\n ],\n \"d\": []\n}\n"},
+	}
+
+	json, err := ParseString(input)
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	for i, tt := range tests {
+		got := json.Indent(tt.prefix, tt.indent, tt.opts...)
+		if got != tt.expected {
+			t.Errorf("[test %d] output wrong.\ngot=\n%s\nwant=\n%s", i, got, tt.expected)
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	tests := []struct {
+		input    string
+		opts     []FormatOption
+		expected string
+	}{
+		{"{ \"b\" : [ 1 , -2.5 ] ,\n \"a\" : { } }", nil, `{"b":[1,-2.5],"a":{}}`},
+		{`{"b": 1, "a": {"z": 1, "y": 2}}`, []FormatOption{SortKeys()}, `{"a":{"y":2,"z":1},"b":1}`},
+		{`"x"`, []FormatOption{TrailingNewline()}, "\"x\"\n"},
+		{`{"é": "日本 😀"}`, []FormatOption{ASCIIOnly()}, `{"\u00e9":"\u65e5\u672c \ud83d\ude00"}`},
+		{"\"a\xffb\"", []FormatOption{ASCIIOnly()}, `"a\ufffdb"`},
+	}
+
+	for i, tt := range tests {
+		json, err := ParseString(tt.input)
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+
+		got := json.Compact(tt.opts...)
+		if got != tt.expected {
+			t.Errorf("[test %d] output wrong - got=%s, want=%s.", i, got, tt.expected)
+		}
+
+		parsed, err := ParseString(got)
+		if err != nil {
+			t.Fatalf("[test %d] unexpected error - %q", i, err)
+		}
+		if parsed.Compact(tt.opts...) != got {
+			t.Errorf("[test %d] reparsed output wrong - got=%s, want=%s.", i, parsed.Compact(tt.opts...), got)
+		}
+	}
+}
+
+func TestSortKeysDuplicates(t *testing.T) {
+	json, err := ParseString(`{"b": 1, "a": 2, "b": 3}`, WithDuplicateKeys(DuplicateKeyKeepAll))
+	if err != nil {
+		t.Fatalf("unexpected error - %q", err)
+	}
+
+	expected := `{"a":2,"b":1,"b":3}`
+	if got := json.Compact(SortKeys()); got != expected {
+		t.Errorf("output wrong - got=%s, want=%s.", got, expected)
+	}
+	if got := json.String(); got != `{"b": 1, "a": 2, "b": 3}` {
+		t.Errorf("SortKeys changed the document - got=%s.", got)
+	}
+}